@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	sinkSign   = "OUT"
+	micSign    = "MIC"
+	volumeSign = "VOL"
+)
+
+var (
+	micSource      = flag.String("mic-source", "@DEFAULT_SOURCE@", "PulseAudio/PipeWire source whose mute state is shown")
+	micHideUnmuted = flag.Bool("mic-hide-unmuted", false, "omit the microphone segment while the source is not muted")
+
+	sinkLabels = flag.String("sink-labels", "", "friendly names of audio sinks as name=label list, e.g. alsa_output.usb-Headset.analog-stereo=headphones")
+	sinkWidth  = flag.Int("sink-width", 16, "maximum length of the audio sink name (unlimited if <= 0)")
+
+	volumeBackend = flag.String("volume-backend", "pactl", "mixer queried by the volume segment: pactl, wpctl or amixer")
+)
+
+// updateSink shows the default PulseAudio/PipeWire sink. It is omitted when
+// pactl fails.
+func updateSink() string {
+	out, err := runCommand("pactl", "get-default-sink")
+
+	if err != nil {
+		return ""
+	}
+
+	name := strings.TrimSpace(string(out))
+
+	if label, ok := lookup(parsePairs(*sinkLabels), name); ok {
+		name = label
+	}
+
+	return sinkSign + " " + truncate(name, *sinkWidth)
+}
+
+// updateMic shows whether the microphone source is muted
+func updateMic() string {
+	out, err := runCommand("pactl", "get-source-mute", *micSource)
+
+	if err != nil {
+		return micSign + " " + errText(err)
+	}
+
+	if strings.Contains(string(out), "yes") {
+		return micSign + " mute"
+	}
+
+	if *micHideUnmuted {
+		return ""
+	}
+
+	return micSign
+}
+
+// volumePercent finds the first "45%" of pactl and amixer output
+var volumePercent = regexp.MustCompile(`(\d+)%`)
+
+// readVolume returns the volume of the default sink in percent and whether it
+// is muted from the -volume-backend
+func readVolume() (int, bool, error) {
+	switch *volumeBackend {
+	case "wpctl":
+		// Volume: 0.45 [MUTED]
+		out, err := runCommand("wpctl", "get-volume", "@DEFAULT_AUDIO_SINK@")
+
+		if err != nil {
+			return 0, false, err
+		}
+
+		fields := strings.Fields(string(out))
+
+		if len(fields) < 2 || fields[0] != "Volume:" {
+			return 0, false, errors.New("wpctl: unexpected output")
+		}
+
+		volume, err := strconv.ParseFloat(fields[1], 64)
+
+		// wpctl rounds to two decimals, i.e. whole percents
+		return int(math.Round(volume * 100)), strings.Contains(string(out), "[MUTED]"), err
+	case "amixer":
+		//   Front Left: Playback 29491 [45%] [on]
+		out, err := runCommand("amixer", "get", "Master")
+
+		if err != nil {
+			return 0, false, err
+		}
+
+		match := volumePercent.FindSubmatch(out)
+
+		if match == nil {
+			return 0, false, errors.New("amixer: no volume of Master")
+		}
+
+		volume, _ := strconv.Atoi(string(match[1]))
+
+		return volume, strings.Contains(string(out), "[off]"), nil
+	}
+
+	// Volume: front-left: 29491 /  45% / -20.81 dB, ...
+	out, err := runCommand("pactl", "get-sink-volume", "@DEFAULT_SINK@")
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	match := volumePercent.FindSubmatch(out)
+
+	if match == nil {
+		return 0, false, errors.New("pactl: no volume of the default sink")
+	}
+
+	volume, _ := strconv.Atoi(string(match[1]))
+	mute, err := runCommand("pactl", "get-sink-mute", "@DEFAULT_SINK@")
+
+	return volume, strings.Contains(string(mute), "yes"), err
+}
+
+// updateVolume shows the volume of the default sink, e.g. VOL 45 or VOL mute
+func updateVolume() string {
+	volume, muted, err := readVolume()
+
+	if err != nil {
+		return volumeSign + " " + errText(err)
+	}
+
+	if muted {
+		return volumeSign + " mute"
+	}
+
+	return volumeSign + " " + formatInt(volume)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"path/filepath"
+)
+
+const brightSign = "☀"
+
+var brightnessSteps = flag.Int("brightness-steps", 0, "show the backlight as current/steps instead of a percentage, e.g. 10 (percentage if <= 0)")
+
+// updateBrightness shows the brightness of the first backlight. Without a
+// backlight the segment is omitted.
+func updateBrightness() string {
+	devices, _ := filepath.Glob(*sysfsRoot + "/class/backlight/*")
+
+	if len(devices) == 0 {
+		return ""
+	}
+
+	now, err := readInt(devices[0] + "/brightness")
+
+	if err != nil {
+		return brightSign + " " + errText(err)
+	}
+
+	max, err := readInt(devices[0] + "/max_brightness")
+
+	if err == nil && max <= 0 {
+		err = errors.New("max_brightness is not positive")
+	}
+
+	if err != nil {
+		return brightSign + " " + errText(err)
+	}
+
+	if steps := *brightnessSteps; steps > 0 {
+		return brightSign + " " + formatInt((now*steps+max/2)/max) + "/" + formatInt(steps)
+	}
+
+	return brightSign + " " + formatInt(percent(float64(now), float64(max)))
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var barList = flag.String("lines", "", "independent status lines as name=segments>output entries separated by \";\", the output being xsetroot, stdout, waybar, preview or a file "+
+	"to append to, e.g. \"left=hostname,cpu,mem>stdout;right=date>xsetroot\" (one line of -segments to -output if empty)")
+
+// bar is a status line of its own with the segments and output it shows
+type bar struct {
+	name     string
+	segments []segment
+	output   string
+}
+
+// bars are the configured -lines
+var bars []bar
+
+// parseBars reads the -lines list. Entries without an output use -output.
+func parseBars(list string) ([]bar, error) {
+	var parsed []bar
+
+	for _, entry := range strings.Split(list, ";") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+
+		kv := strings.SplitN(entry, "=", 2)
+
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("line %q has no name", entry)
+		}
+
+		b := bar{name: strings.TrimSpace(kv[0]), output: *outputMode}
+		names := kv[1]
+
+		if at := strings.LastIndex(names, ">"); at >= 0 {
+			names, b.output = names[:at], strings.TrimSpace(names[at+1:])
+		}
+
+		if strings.TrimSpace(names) == "" {
+			return nil, fmt.Errorf("line %s has no segments", b.name)
+		}
+
+		segs, err := selectSegments(names)
+
+		if err != nil {
+			return nil, fmt.Errorf("line %s: %v", b.name, err)
+		}
+
+		b.segments = segs
+		parsed = append(parsed, b)
+	}
+
+	return parsed, nil
+}
+
+// updateBars runs every segment used by any line once and writes the lines
+// to their outputs
+func updateBars() {
+	var all []segment
+	used := make(map[string]bool)
+
+	for _, b := range bars {
+		for _, s := range b.segments {
+			if !used[s.name] {
+				used[s.name] = true
+				all = append(all, s)
+			}
+		}
+	}
+
+	shown := renderSegments(all)
+	setCurrent(shown)
+
+	byName := make(map[string]shownSegment, len(shown))
+
+	for _, s := range shown {
+		byName[s.Name] = s
+	}
+
+	for _, b := range bars {
+		var picked []shownSegment
+
+		for _, s := range b.segments {
+			if text, ok := byName[s.name]; ok {
+				picked = append(picked, text)
+			}
+		}
+
+		outputTo(b.output, truncate(sanitize(joinSegments(picked)), *maxLength))
+	}
+}
+
+// appendLine writes a status line to the end of a file, e.g. a FIFO read by
+// a bar like lemonbar
+func appendLine(path, line string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	_, err = fmt.Fprintln(file, line)
+
+	return err
+}
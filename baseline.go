@@ -0,0 +1,21 @@
+package main
+
+import "flag"
+
+var firstPlaceholder = flag.String("first-placeholder", "--", "shown by the segments computing rates or deltas on the first update, while their baseline is taken")
+
+// baselines records the delta segments which took their first sample
+var baselines = map[string]bool{}
+
+// firstSample tells whether a delta segment takes its first sample, i.e. has
+// no previous one to compute a rate from. Such segments show the
+// -first-placeholder instead of their value on that update.
+func firstSample(name string) bool {
+	if baselines[name] {
+		return false
+	}
+
+	baselines[name] = true
+
+	return true
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"strings"
+)
+
+const btSign = "BT"
+
+var showBluetooth = flag.Bool("bluetooth", true, "show bluetooth power state and count of connected devices")
+
+// updateBluetooth shows whether the adapter is powered and how many devices
+// are connected. Without an adapter the segment is omitted, bluetoothctl
+// hanging without a running bluetoothd is killed after -exec-timeout.
+func updateBluetooth() string {
+	if !*showBluetooth {
+		return ""
+	}
+
+	if adapters, err := ioutil.ReadDir(*sysfsRoot + "/class/bluetooth"); err != nil || len(adapters) == 0 {
+		return ""
+	}
+
+	show, err := runCommand("bluetoothctl", "show")
+
+	if err != nil {
+		return btSign + " " + errText(err)
+	}
+
+	if !bytes.Contains(show, []byte("Powered: yes")) {
+		return btSign + " off"
+	}
+
+	devices, err := runCommand("bluetoothctl", "devices", "Connected")
+
+	if err != nil {
+		return btSign + " " + errText(err)
+	}
+
+	var connected = 0
+
+	for scanner := bufio.NewScanner(bytes.NewReader(devices)); scanner.Scan(); {
+		if strings.HasPrefix(scanner.Text(), "Device ") {
+			connected++
+		}
+	}
+
+	return btSign + " " + formatInt(connected)
+}
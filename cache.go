@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"sync"
+	"time"
+)
+
+var (
+	staleAfter = flag.Int("stale", 3, "mark background segments not refreshed successfully within this many of their intervals (disabled if <= 0)")
+	staleMark  = flag.String("stale-mark", "*", "appended to the value of stale segments")
+)
+
+// cache holds the last value of a segment that is updated in the background,
+// so the main loop never has to wait for it
+type cache struct {
+	sync.Mutex
+	value   string
+	updated time.Time     // time of the last set
+	maxAge  time.Duration // age after which the value is marked stale, 0 never
+}
+
+// set replaces the cached value
+func (c *cache) set(value string) {
+	c.Lock()
+	c.value, c.updated = value, time.Now()
+	c.Unlock()
+}
+
+// get returns the cached value, which is empty until the first set. Values
+// older than maxAge get the -stale-mark appended.
+func (c *cache) get() string {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.value != "" && c.maxAge > 0 && time.Since(c.updated) > c.maxAge {
+		return c.value + *staleMark
+	}
+
+	return c.value
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+var (
+	execRetries = flag.Int("exec-retries", 3, "consecutive failures of an external command which reuse its last output before the segment shows the failure")
+	execTimeout = flag.Duration("exec-timeout", 2*time.Second, "time after which an external command of a segment is killed and counts as failed")
+)
+
+// commandResult is the last successful output of a command line and the count
+// of failures since then
+type commandResult struct {
+	out      []byte
+	failures int
+}
+
+// commandResults are keyed by the command line, only the status loop runs
+// commands
+var commandResults = map[string]*commandResult{}
+
+// runCommand returns the output of a command like exec.Command(...).Output().
+// Commands running longer than -exec-timeout are killed, so a hanging tool
+// can't freeze the bar. Failures of a command which succeeded before return its last output for up
+// to -exec-retries consecutive updates, so restarts of services like dbus or
+// PulseAudio don't flash ERR in the bar.
+func runCommand(name string, args ...string) ([]byte, error) {
+	key := name + "\x00" + strings.Join(args, "\x00")
+	ctx, cancel := context.WithTimeout(context.Background(), *execTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("%s timed out after %v", name, *execTimeout)
+	}
+
+	result, seen := commandResults[key]
+
+	if err == nil {
+		commandResults[key] = &commandResult{out: out}
+		return out, nil
+	}
+
+	if !seen || result.failures >= *execRetries {
+		return nil, err
+	}
+
+	result.failures++
+
+	return result.out, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os/exec"
+	"time"
+)
+
+var (
+	containerRuntime   = flag.String("container-runtime", "docker", "runtime whose running containers the containers segment counts: docker or podman")
+	containersInterval = flag.Duration("containers-interval", 30*time.Second, "time between two counts of running containers")
+
+	containers cache // last count of running containers
+)
+
+// containerSigns label the containers segment by runtime
+var containerSigns = map[string]string{"docker": "DKR", "podman": "POD"}
+
+// fetchContainers counts the running containers. Without the runtime
+// installed the segment is omitted; while its daemon is down it shows a dash
+// instead of ERR.
+func fetchContainers() error {
+	sign := containerSigns[*containerRuntime]
+
+	if _, err := exec.LookPath(*containerRuntime); err != nil {
+		return nil
+	}
+
+	out, err := exec.Command(*containerRuntime, "ps", "-q").Output()
+
+	if err != nil {
+		containers.set(sign + " -")
+		return err
+	}
+
+	containers.set(sign + " " + formatInt(len(bytes.Fields(out))))
+
+	return nil
+}
+
+// updateContainers returns the cached count of running containers
+func updateContainers() string {
+	return containers.get()
+}
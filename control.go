@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	controlSocket = flag.String("control-socket", "", "listen for the line based commands reload, pause, resume and status on this unix socket (disabled if empty)")
+
+	// wake requests an update of the status line before the next tick
+	wake = make(chan struct{}, 1)
+
+	// paused stops updating the bar, which keeps showing the last status
+	paused struct {
+		sync.Mutex
+		on bool
+	}
+)
+
+// isPaused reports whether updates of the bar are suspended
+func isPaused() bool {
+	paused.Lock()
+	defer paused.Unlock()
+
+	return paused.on
+}
+
+// setPaused suspends or resumes updates of the bar
+func setPaused(on bool) {
+	paused.Lock()
+	paused.on = on
+	paused.Unlock()
+
+	if !on {
+		requestUpdate()
+	}
+}
+
+// togglePaused resumes a paused bar or pauses a running one
+func togglePaused() {
+	paused.Lock()
+	on := !paused.on
+	paused.Unlock()
+
+	setPaused(on)
+}
+
+// requestUpdate wakes the main loop without waiting for the next tick
+func requestUpdate() {
+	select {
+	case wake <- struct{}{}:
+	default: // an update is already pending
+	}
+}
+
+// reload refreshes all background segments and then the status line
+func reload() {
+	for _, j := range jobs {
+		j.refresh()
+	}
+
+	requestUpdate()
+}
+
+// handleSignals reloads on SIGHUP and toggles pause on SIGUSR1
+func handleSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGUSR1)
+
+	go func() {
+		for sig := range signals {
+			if sig == syscall.SIGUSR1 {
+				togglePaused()
+			} else {
+				reload()
+			}
+		}
+	}()
+}
+
+// listenControl accepts connections on the control socket in the background
+func listenControl(path string) error {
+	os.Remove(path) // left over by a previous run
+
+	listener, err := net.Listen("unix", path)
+
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		var delay time.Duration // backoff after failed accepts, like net/http
+
+		for {
+			conn, err := listener.Accept()
+
+			if errors.Is(err, net.ErrClosed) {
+				return
+			} else if err != nil {
+				if delay = 2 * delay; delay == 0 {
+					delay = 5 * time.Millisecond
+				} else if delay > time.Second {
+					delay = time.Second
+				}
+
+				log.Printf("control socket: %v, retrying in %v", err, delay)
+				time.Sleep(delay)
+
+				continue
+			}
+
+			delay = 0
+
+			go handleControl(conn)
+		}
+	}()
+
+	return nil
+}
+
+// handleControl executes one command per line and answers each with a line
+func handleControl(conn net.Conn) {
+	defer conn.Close()
+
+	for scanner := bufio.NewScanner(conn); scanner.Scan(); {
+		switch command := strings.TrimSpace(scanner.Text()); command {
+		case "reload":
+			reload()
+			fmt.Fprintln(conn, "ok")
+		case "pause":
+			setPaused(true)
+			fmt.Fprintln(conn, "ok")
+		case "resume":
+			setPaused(false)
+			fmt.Fprintln(conn, "ok")
+		case "status":
+			state := "running"
+
+			if isPaused() {
+				state = "paused"
+			}
+
+			var texts []string
+
+			for _, s := range getCurrent() {
+				texts = append(texts, plain(s.Text))
+			}
+
+			fmt.Fprintf(conn, "%s: %s\n", state, strings.Join(texts, fieldSeparator))
+		case "":
+		default:
+			fmt.Fprintf(conn, "unknown command %q\n", command)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const (
+	govSign      = "GOV"
+	stealSign    = "STEAL"
+	throttleSign = "THR"
+)
+
+// cpuTimes is the aggregate cpu line of /proc/stat in USER_HZ ticks. The guest
+// times are already contained in user and nice.
+type cpuTimes struct {
+	user, nice, system, idle, iowait, irq, softirq, steal uint64
+}
+
+// total sums all states of the times
+func (t cpuTimes) total() uint64 {
+	return t.user + t.nice + t.system + t.idle + t.iowait + t.irq + t.softirq + t.steal
+}
+
+var (
+	lastCPUTimes      cpuTimes // sample of the previous steal update
+	lastThrottleCount = -1     // core_throttle_count at the previous update, -1 before the first
+)
+
+// updateGovernor reads the cpufreq scaling governor of the first core. Without
+// cpufreq support the segment is omitted.
+func updateGovernor() string {
+	tmp, err := ioutil.ReadFile(*sysfsRoot + "/devices/system/cpu/cpu0/cpufreq/scaling_governor")
+
+	if err != nil {
+		return ""
+	}
+
+	return govSign + " " + strings.TrimSpace(string(tmp))
+}
+
+// readCPUTimes parses the aggregate cpu line of /proc/stat. Older kernels
+// without the later columns leave them zero.
+func readCPUTimes() (cpuTimes, error) {
+	var t cpuTimes
+	file, err := os.Open(*procfsRoot + "/stat")
+
+	if err != nil {
+		return t, err
+	}
+
+	defer file.Close()
+
+	for scanner := bufio.NewScanner(file); scanner.Scan(); {
+		line := scanner.Text()
+
+		if !strings.HasPrefix(line, "cpu ") {
+			continue
+		}
+
+		n, err := fmt.Sscan(line[4:], &t.user, &t.nice, &t.system, &t.idle, &t.iowait, &t.irq, &t.softirq, &t.steal)
+
+		if n < 4 {
+			return t, err
+		}
+
+		return t, nil
+	}
+
+	return t, errors.New("no cpu line in stat")
+}
+
+// updateSteal shows the share of time the hypervisor ran other guests while
+// this one wanted to run since the last update
+func updateSteal() string {
+	now, err := readCPUTimes()
+
+	if err != nil {
+		return stealSign + " " + errText(err)
+	}
+
+	last := lastCPUTimes
+	lastCPUTimes = now
+
+	if firstSample("steal") {
+		return stealSign + " " + *firstPlaceholder
+	}
+
+	return stealSign + " " + formatInt(percent(float64(now.steal-last.steal), float64(now.total()-last.total()))) + "%"
+}
+
+// updateThrottle shows a mark while the first core is thermally throttled,
+// i.e. its throttle count rose since the last update. It is omitted otherwise
+// and on CPUs without thermal_throttle.
+func updateThrottle() string {
+	count, err := readInt(*sysfsRoot + "/devices/system/cpu/cpu0/thermal_throttle/core_throttle_count")
+
+	if err != nil {
+		return ""
+	}
+
+	last := lastThrottleCount
+	lastThrottleCount = count
+
+	if last < 0 || count <= last {
+		return ""
+	}
+
+	return colorCritical + throttleSign + colorNormal
+}
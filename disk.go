@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+const homeSign = "HOME"
+
+// usedPercent returns the share of the filesystem holding path in use, the
+// way df computes it (blocks reserved for root count as unavailable)
+func usedPercent(path string) (int, error) {
+	var fs syscall.Statfs_t
+
+	if err := syscall.Statfs(path, &fs); err != nil {
+		return 0, err
+	}
+
+	used := fs.Blocks - fs.Bfree
+
+	return percent(float64(used), float64(used+fs.Bavail)), nil
+}
+
+// updateHome shows the usage of the filesystem holding $HOME
+func updateHome() string {
+	deleteMetric("gods_home_used_percent")
+	home := os.Getenv("HOME")
+
+	if home == "" {
+		return ""
+	}
+
+	percentage, err := usedPercent(home)
+
+	if err != nil {
+		return homeSign + " " + errText(err)
+	}
+
+	setMetric("gods_home_used_percent", float64(percentage))
+
+	return colored(homeSign, percentage)
+}
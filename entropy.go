@@ -0,0 +1,31 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+const entropySign = "ENT"
+
+var entropyLow = flag.Int("entropy-low", 256, "available entropy in bits below which the ent segment gets the critical color")
+
+// updateEntropy shows the bits in the kernel entropy pool. The segment is
+// omitted where the pool isn't exposed.
+func updateEntropy() string {
+	bits, err := readInt(*procfsRoot + "/sys/kernel/random/entropy_avail")
+
+	if os.IsNotExist(err) {
+		return ""
+	} else if err != nil {
+		return entropySign + " " + errText(err)
+	}
+
+	color := colorNormal
+
+	if bits < *entropyLow {
+		color = colorCritical
+	}
+
+	return fmt.Sprintf("%s%s%s %s", color, entropySign, colorNormal, formatInt(bits))
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+var (
+	fileList     = flag.String("files", "", "files whose first line is shown as label=path list, an optional @interval per path overrides -files-interval, e.g. mail=/tmp/mystatus@1m")
+	fileInterval = flag.Duration("files-interval", 10*time.Second, "default time between two reads of a -files entry")
+
+	watchedFiles []*watchedFile
+)
+
+// watchedFile is a single -files entry, read in the background
+type watchedFile struct {
+	label, path string
+	content     cache
+}
+
+// read caches the trimmed first line of the file, or nothing if it is missing
+func (f *watchedFile) read() error {
+	tmp, err := ioutil.ReadFile(f.path)
+
+	if err != nil {
+		f.content.set("")
+		return nil // the file may just not be written yet, don't back off
+	}
+
+	f.content.set(strings.TrimSpace(strings.SplitN(string(tmp), "\n", 2)[0]))
+
+	return nil
+}
+
+// watchFiles starts a reader for every -files entry
+func watchFiles() {
+	for _, entry := range parsePairs(*fileList) {
+		f := &watchedFile{label: entry.key, path: entry.value}
+		interval := *fileInterval
+
+		if at := strings.LastIndex(entry.value, "@"); at >= 0 {
+			if d, err := time.ParseDuration(entry.value[at+1:]); err == nil {
+				f.path, interval = entry.value[:at], d
+			}
+		}
+
+		watchedFiles = append(watchedFiles, f)
+		startJob("files", interval, &f.content, f.read)
+	}
+}
+
+// updateFiles shows the label and content of every non-empty watched file
+func updateFiles() string {
+	var shown []string
+
+	for _, f := range watchedFiles {
+		if content := f.content.get(); content != "" {
+			shown = append(shown, f.label+" "+content)
+		}
+	}
+
+	return strings.Join(shown, fieldSeparator)
+}
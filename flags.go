@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// pair is a single key=value entry of a list flag
+type pair struct {
+	key, value string
+}
+
+// parsePairs splits a comma separated list of key=value entries, keeping their
+// order. Entries without "=" are skipped.
+func parsePairs(list string) []pair {
+	var pairs []pair
+
+	for _, entry := range strings.Split(list, ",") {
+		if kv := strings.SplitN(entry, "=", 2); len(kv) == 2 {
+			pairs = append(pairs, pair{strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])})
+		}
+	}
+
+	return pairs
+}
+
+// lookup returns the value of the first pair with the given key
+func lookup(pairs []pair, key string) (string, bool) {
+	for _, p := range pairs {
+		if p.key == key {
+			return p.value, true
+		}
+	}
+
+	return "", false
+}
+
+// validateConfig checks the flags for misconfigurations. Errors make the
+// configuration unusable, warnings may resolve themselves, like an interface
+// which is not up yet.
+func validateConfig() (errs, warnings []string) {
+	fail := func(format string, args ...interface{}) { errs = append(errs, fmt.Sprintf(format, args...)) }
+	warn := func(format string, args ...interface{}) { warnings = append(warnings, fmt.Sprintf(format, args...)) }
+
+	if *interval <= 0 {
+		fail("-interval must be positive, got %v", *interval)
+	}
+
+	if *execTimeout <= 0 {
+		fail("-exec-timeout must be positive, got %v", *execTimeout)
+	}
+
+	if _, err := selectSegments(*segmentList); err != nil {
+		fail("-segments: %v", err)
+	}
+
+	if _, err := parseBars(*barList); err != nil {
+		fail("-lines: %v", err)
+	}
+
+	for _, p := range parsePairs(*padList) {
+		if _, ok := findSegment(p.key); !ok {
+			fail("-pad: unknown segment %q", p.key)
+		}
+	}
+
+	for name := range parseGroups(*groupList) {
+		if _, ok := findSegment(name); !ok {
+			fail("-groups: unknown segment %q", name)
+		}
+	}
+
+	for name := range parseCollapse(*collapseList) {
+		if _, ok := segmentMetrics[name]; !ok {
+			fail("-collapse: segment %q has no value to compare", name)
+		}
+	}
+
+	if *outputMode != "xsetroot" && *outputMode != "stdout" && *outputMode != "waybar" && *outputMode != "preview" {
+		fail("-output must be xsetroot, stdout, waybar or preview, got %q", *outputMode)
+	}
+
+	if *netDisplay != "arrows" && *netDisplay != "rates" && *netDisplay != "total" {
+		fail("-net-display must be arrows, rates or total, got %q", *netDisplay)
+	}
+
+	if *netRateFormat != "auto" && *netRateFormat != "fixed-unit" && *netRateFormat != "fixed-width" {
+		fail("-net-rate-format must be auto, fixed-unit or fixed-width, got %q", *netRateFormat)
+	}
+
+	if *netRateFormat == "fixed-unit" {
+		var known = false
+
+		for _, unit := range units(*rateByteBase) {
+			known = known || unit == *netRateUnit
+		}
+
+		if !known {
+			fail("-net-rate-unit %q is no unit of -rate-byte-base %d", *netRateUnit, *rateByteBase)
+		}
+	}
+
+	if *loadColumn != 1 && *loadColumn != 5 && *loadColumn != 15 {
+		fail("-load-column must be 1, 5 or 15, got %d", *loadColumn)
+	}
+
+	for _, resource := range strings.Split(*psiResources, ",") {
+		if _, ok := psiLabels[strings.TrimSpace(resource)]; !ok {
+			fail("-psi: unknown resource %q", resource)
+		}
+	}
+
+	if *separatorColor < 0 || *separatorColor >= ' ' || *separatorColor == '\n' {
+		fail("-segment-separator-color must be a statuscolors index from 1 to 31 except 10, got %d", *separatorColor)
+	}
+
+	if *sensorsBackend != "sysfs" && *sensorsBackend != "lm-sensors" {
+		fail("-sensors-backend must be sysfs or lm-sensors, got %q", *sensorsBackend)
+	}
+
+	if *tempUnit != "C" && *tempUnit != "F" {
+		fail("-temp-unit must be C or F, got %q", *tempUnit)
+	}
+
+	if *cpuPercentile > 100 {
+		fail("-cpu-percentile must be at most 100, got %d", *cpuPercentile)
+	}
+
+	if *cpuPercentile > 0 && *cpuWindow <= 0 {
+		fail("-cpu-window must be positive, got %d", *cpuWindow)
+	}
+
+	if *powerBackend != "sysfs" && *powerBackend != "upower" {
+		fail("-power-backend must be sysfs or upower, got %q", *powerBackend)
+	}
+
+	if *batteryTime != "auto" && *batteryTime != "reserve" && *batteryTime != "never" {
+		fail("-battery-time must be auto, reserve or never, got %q", *batteryTime)
+	}
+
+	if *noBatteryMode != "omit" && *noBatteryMode != "label" && *noBatteryMode != "err" {
+		fail("-no-battery must be omit, label or err, got %q", *noBatteryMode)
+	}
+
+	if _, ok := containerSigns[*containerRuntime]; !ok {
+		fail("-container-runtime must be docker or podman, got %q", *containerRuntime)
+	}
+
+	for _, p := range []int{*precision, *tempPrecision} {
+		if p < 0 || p > 3 {
+			fail("precisions must be from 0 to 3 decimals, got %d", p)
+		}
+	}
+
+	if *volumeBackend != "pactl" && *volumeBackend != "wpctl" && *volumeBackend != "amixer" {
+		fail("-volume-backend must be pactl, wpctl or amixer, got %q", *volumeBackend)
+	}
+
+	if *roundMode != "down" && *roundMode != "nearest" {
+		fail("-round must be down or nearest, got %q", *roundMode)
+	}
+
+	if *batteryCritical > *batteryWarning {
+		fail("-battery-critical (%d) must not be above -battery-warning (%d)", *batteryCritical, *batteryWarning)
+	}
+
+	for _, base := range []int{*byteBase, *rateByteBase} {
+		if base != 1000 && base != 1024 {
+			fail("byte bases must be 1000 or 1024, got %d", base)
+		}
+	}
+
+	if time.Now().Format(dateFormat) == dateFormat {
+		warn("the date format %q contains no time fields", dateFormat)
+	}
+
+	if ifaces, err := readNetDev(); err == nil {
+		present := make(map[string]bool, len(ifaces))
+
+		for _, iface := range ifaces {
+			present[iface.name] = true
+		}
+
+		for name := range netDevs {
+			if !present[name] {
+				warn("-net-interfaces: %s is not present (yet)", name)
+			}
+		}
+	}
+
+	for _, f := range parsePairs(*fileList) {
+		path := f.value
+
+		if at := strings.LastIndex(path, "@"); at >= 0 {
+			if _, err := time.ParseDuration(path[at+1:]); err != nil {
+				fail("-files: invalid interval in %q", f.value)
+			}
+			path = path[:at]
+		}
+
+		if _, err := os.Stat(path); err != nil {
+			warn("-files: %v", err)
+		}
+	}
+
+	return errs, warnings
+}
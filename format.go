@@ -0,0 +1,287 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	decimalSeparator   = flag.String("decimal-separator", floatSeparator, "separator between the integer and fractional part of numbers")
+	thousandsSeparator = flag.String("thousands-separator", "", "separator between groups of thousands in numbers, e.g. \",\" (none if empty)")
+	byteBase           = flag.Int("byte-base", 1024, "unit base of memory and disk sizes: 1024 (KiB, MiB, …) or 1000 (KB, MB, …)")
+	rateByteBase       = flag.Int("rate-byte-base", 1000, "unit base of network rates: 1024 (KiB, MiB, …) or 1000 (KB, MB, …)")
+	precision          = flag.Int("precision", 1, "decimals of fractional values like rates and pressure, 0 to 3")
+	roundMode          = flag.String("round", "down", "rounding of percentages: down or nearest")
+)
+
+var (
+	binaryUnits  = []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	decimalUnits = []string{"B", "KB", "MB", "GB", "TB"}
+)
+
+// group inserts the thousands separator into a string of decimal digits
+func group(digits string) string {
+	if *thousandsSeparator == "" || len(digits) <= 3 {
+		return digits
+	}
+
+	var grouped strings.Builder
+	head := len(digits) % 3
+
+	if head == 0 {
+		head = 3
+	}
+
+	grouped.WriteString(digits[:head])
+
+	for i := head; i < len(digits); i += 3 {
+		grouped.WriteString(*thousandsSeparator)
+		grouped.WriteString(digits[i : i+3])
+	}
+
+	return grouped.String()
+}
+
+// percent scales part of whole to [0, 100] and rounds as set by -round. The
+// thresholds of the colors are compared with the rounded value.
+func percent(part, whole float64) int {
+	if whole == 0 {
+		return 0
+	}
+
+	if *roundMode == "nearest" {
+		return int(math.Round(part * 100 / whole))
+	}
+
+	return int(math.Floor(part * 100 / whole))
+}
+
+// gauge renders a percentage as width blocks, e.g. ▓▓▓░░ for 60, rounding to
+// the nearest block
+func gauge(percentage, width int) string {
+	filled := (percentage*width + 50) / 100
+
+	if filled < 0 {
+		filled = 0
+	} else if filled > width {
+		filled = width
+	}
+
+	return strings.Repeat("▓", filled) + strings.Repeat("░", width-filled)
+}
+
+// formatInt renders an integer with the configured thousands separator
+func formatInt(n int) string {
+	if n < 0 {
+		return "-" + group(strconv.Itoa(-n))
+	}
+
+	return group(strconv.Itoa(n))
+}
+
+// formatFloat renders a fractional value with -precision decimals and the
+// configured separators
+func formatFloat(f float64) string {
+	return formatFixed(f, *precision)
+}
+
+// formatFixed renders a fractional value with the given count of decimals and
+// the configured separators
+func formatFixed(f float64, decimals int) string {
+	text := strconv.FormatFloat(f, 'f', decimals, 64)
+	sign := ""
+
+	if strings.HasPrefix(text, "-") {
+		sign, text = "-", text[1:]
+	}
+
+	whole, fraction := text, ""
+
+	if dot := strings.IndexByte(text, '.'); dot >= 0 {
+		whole, fraction = text[:dot], text[dot+1:]
+	}
+
+	if fraction == "" {
+		return sign + group(whole)
+	}
+
+	return sign + group(whole) + *decimalSeparator + fraction
+}
+
+// truncate shortens s to at most width visible runes, marking the cut with an
+// ellipsis. The dwm color escapes don't count. A width <= 0 leaves s
+// untouched.
+func truncate(s string, width int) string {
+	if width <= 0 || visibleLen(s) <= width {
+		return s
+	}
+
+	var cut strings.Builder
+	var n = 0
+
+	for _, r := range s {
+		if !unicode.IsControl(r) {
+			if n == width-1 {
+				break
+			}
+			n++
+		}
+
+		cut.WriteRune(r)
+	}
+
+	return cut.String() + "…"
+}
+
+// visibleLen counts the runes of s which take up space in the bar, ignoring
+// the dwm color escapes
+func visibleLen(s string) int {
+	var n = 0
+
+	for _, r := range s {
+		if !unicode.IsControl(r) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// padField fills s with spaces up to width visible runes. With align 'r' the
+// spaces are prepended, otherwise appended.
+func padField(s string, width int, align rune) string {
+	fill := width - visibleLen(s)
+
+	if fill <= 0 {
+		return s
+	}
+
+	if align == 'r' {
+		return strings.Repeat(" ", fill) + s
+	}
+
+	return s + strings.Repeat(" ", fill)
+}
+
+// plain removes the dwm color escapes from s
+func plain(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// sanitize makes s safe for the bar: line breaks and tabs become spaces,
+// invalid UTF-8 and control characters other than the color escapes of the
+// theme and -segment-separator-color are dropped
+func sanitize(s string) string {
+	var clean strings.Builder
+
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		s = s[size:]
+
+		switch {
+		case r == utf8.RuneError && size == 1:
+			continue
+		case r == '\n' || r == '\r' || r == '\t':
+			r = ' '
+		case (r < ' ' || r == 0x7f || (r >= 0x80 && r < 0xa0)) && !isColorEscape(r):
+			continue
+		}
+
+		clean.WriteRune(r)
+	}
+
+	return clean.String()
+}
+
+// isColorEscape tells whether r selects one of the statuscolors in use
+func isColorEscape(r rune) bool {
+	if *separatorColor > 0 && r == rune(*separatorColor) {
+		return true
+	}
+
+	return strings.ContainsRune(colorNormal+colorWarning+colorCritical, r)
+}
+
+// units returns the unit names of a byte base, decimal unless it is 1024
+func units(base int) []string {
+	if base == 1024 {
+		return binaryUnits
+	}
+
+	return decimalUnits
+}
+
+// formatBytes scales a byte count to the largest fitting unit of base, which
+// is 1024 for binary or 1000 for decimal units
+func formatBytes(n float64, base int) string {
+	names := units(base)
+
+	if base != 1024 {
+		base = 1000
+	}
+
+	if n < float64(base) {
+		return formatInt(int(n)) + names[0]
+	}
+
+	unit := 0
+
+	for ; n >= float64(base) && unit < len(names)-1; unit++ {
+		n /= float64(base)
+	}
+
+	// values which only reach the base when rounded, e.g. 999.96KB, move up
+	// too, or they'd show as 1000.0KB
+	if unit < len(names)-1 && rounded(n, *precision) >= float64(base) {
+		n /= float64(base)
+		unit++
+	}
+
+	return formatFloat(n) + names[unit]
+}
+
+// rounded returns f as formatFixed shows it with the given count of decimals
+func rounded(f float64, decimals int) float64 {
+	r, _ := strconv.ParseFloat(strconv.FormatFloat(f, 'f', decimals, 64), 64)
+
+	return r
+}
+
+// formatBytesIn renders n bytes in a fixed unit of the base, e.g. 0.3MB
+func formatBytesIn(n float64, base int, unit string) string {
+	if base != 1024 {
+		base = 1000
+	}
+
+	for i, name := range units(base) {
+		if name == unit {
+			return formatFloat(n/math.Pow(float64(base), float64(i))) + name
+		}
+	}
+
+	return formatBytes(n, base)
+}
+
+// formatDuration renders a duration in its largest unit, e.g. 45s, 3m or
+// 2h05m
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return formatInt(int(d/time.Second)) + "s"
+	case d < time.Hour:
+		return formatInt(int(d/time.Minute)) + "m"
+	}
+
+	return fmt.Sprintf("%sh%02dm", formatInt(int(d/time.Hour)), int(d%time.Hour/time.Minute))
+}
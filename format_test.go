@@ -0,0 +1,74 @@
+package main
+
+import "testing"
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    float64
+		base int
+		want string
+	}{
+		{999, 1000, "999B"},
+		{1000, 1000, "1.0KB"},
+		{1023, 1000, "1.0KB"},
+		{1024, 1000, "1.0KB"},
+		{1e6, 1000, "1.0MB"},
+		{1e9, 1000, "1.0GB"},
+		{1e12, 1000, "1.0TB"},
+		{999950, 1000, "1.0MB"}, // rounds up to the next unit
+		{999949, 1000, "999.9KB"},
+		{1e15, 1000, "1000.0TB"}, // no unit above TB
+
+		{999, 1024, "999B"},
+		{1000, 1024, "1000B"},
+		{1023, 1024, "1023B"},
+		{1024, 1024, "1.0KiB"},
+		{1 << 20, 1024, "1.0MiB"},
+		{1 << 30, 1024, "1.0GiB"},
+		{1 << 40, 1024, "1.0TiB"},
+		{1023.96 * 1024, 1024, "1.0MiB"},
+		{1023.94 * 1024, 1024, "1023.9KiB"},
+		{1 << 50, 1024, "1024.0TiB"},
+	}
+
+	for _, test := range tests {
+		if got := formatBytes(test.n, test.base); got != test.want {
+			t.Errorf("formatBytes(%v, %d) = %q, want %q", test.n, test.base, got, test.want)
+		}
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	in := colorWarning + "CPU" + colorNormal + "\x02\x1b[0m a\tb\nc\xff �"
+	want := colorWarning + "CPU" + colorNormal + "[0m a b c �"
+
+	if got := sanitize(in); got != want {
+		t.Errorf("sanitize(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	line := colorWarning + "CPU" + colorNormal + " 80 | MEM 20"
+
+	tests := []struct {
+		width int
+		want  string
+	}{
+		{0, line},
+		{15, line},
+		{8, colorWarning + "CPU" + colorNormal + " 80 …"},
+		{2, colorWarning + "C…"},
+	}
+
+	for _, test := range tests {
+		got := truncate(line, test.width)
+
+		if got != test.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", line, test.width, got, test.want)
+		}
+
+		if test.width > 0 && visibleLen(got) > test.width {
+			t.Errorf("truncate(%q, %d) has %d visible runes", line, test.width, visibleLen(got))
+		}
+	}
+}
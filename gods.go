@@ -12,8 +12,12 @@ package main
 
 import (
 	"bufio"
+	"errors"
+	"flag"
 	"fmt"
 	"io/ioutil"
+	"log"
+	"math"
 	"os"
 	"os/exec"
 	"runtime"
@@ -21,7 +25,17 @@ import (
 	"time"
 )
 
-const (
+const floatSeparator = "."
+
+// color escapes of the statuscolors patch, replaced by the -theme at startup
+var (
+	colorNormal   = "\x01"
+	colorWarning  = "\x03"
+	colorCritical = "\x06"
+)
+
+// labels and separators, replaced by the -preset at startup
+var (
 	unpluggedSign = "BAT"
 	pluggedSign   = "AC"
 
@@ -29,115 +43,210 @@ const (
 	memSign = "MEM"
 	netSign = "NET"
 
-	floatSeparator = "."
 	dateSeparator  = "|"
 	fieldSeparator = " | "
+
+	dateFormat = "Mon 02 " + dateSeparator + " 15:04:05"
 )
 
 var (
-	netDevs = map[string]struct{}{
-		"enp0s25:": {},
-		"wlp4s0:":  {},
-	}
+	sysfsRoot  = flag.String("sysfs", "/sys", "mount point of sysfs, e.g. the host's /sys inside a container or a fixture tree")
+	procfsRoot = flag.String("procfs", "/proc", "mount point of procfs, e.g. the host's /proc inside a container or a fixture tree")
+	outputMode = flag.String("output", "xsetroot", "where to send the status line: xsetroot, stdout, waybar (JSON for a custom module on stdout) or preview")
+	once       = flag.Bool("once", false, "print a single status line and exit")
+	maxLength  = flag.Int("max-length", 0, "cut the status line to this many characters (unlimited if <= 0)")
+	verbose    = flag.Bool("verbose", false, "log the raw values read from sysfs")
+	loadColumn = flag.Int("load-column", 1, "load average used for the cpu segment: 1, 5 or 15 minutes")
+	interval   = flag.Duration("interval", time.Second, "time between two status updates")
+	alignTicks = flag.Bool("interval-align", true, "wake up on multiples of -interval on the wall clock (e.g. :00, :05, … for 5s). This keeps the clock "+
+		"in step, but makes all instances wake up at the same time; without it gods just sleeps -interval after each update and the clock may lag")
+
+	xsetrootFailures = flag.Int("xsetroot-failures", 5, "exit after this many consecutive failed xsetroot calls, e.g. when X went away, "+
+		"so a supervisor can restart gods in the new session (never if <= 0)")
+	xsetrootFailed = 0 // consecutive failures so far
+
+	shortHostname = flag.Bool("short-hostname", false, "show the hostname up to the first dot, e.g. box for box.example.com")
+
+	netDevs = map[string]struct{}{} // the -net-interfaces summed up in the net segment, all physical ones if empty
+
 	cores = runtime.NumCPU() // count of cores to scale cpu usage
-	rxOld = 0
-	txOld = 0
+
+	netSampled time.Time // time of the last read of the interface counters
 )
 
 // updateNetUse reads current transfer rates of certain network interfaces
 func updateNetUse() string {
-	file, err := os.Open("/proc/net/dev")
+	deleteMetric("gods_network_receive_bytes_per_second", "gods_network_transmit_bytes_per_second")
+	ifaces, err := readNetDev()
 
 	if err != nil {
-		return netSign + " ERR"
+		return netSign + " " + errText(err)
 	}
 
-	defer file.Close()
+	trackInterfaces(ifaces)
 
-	var void = 0 // target for unused values
-	var dev, rx, tx, rxNow, txNow = "", 0, 0, 0, 0
-	var scanner = bufio.NewScanner(file)
+	var rxDelta, txDelta, busiest = 0, 0, 0
+
+	for name, stat := range ifaceStats {
+		if !summedInterface(name, stat) {
+			continue
+		}
 
-	for scanner.Scan() {
-		_, err = fmt.Sscanf(
-			scanner.Text(),
-			"%s %d %d %d %d %d %d %d %d %d",
-			&dev, &rx, &void, &void, &void, &void, &void, &void, &void, &tx,
-		)
+		rxDelta += stat.rxDelta
+		txDelta += stat.txDelta
 
-		if _, ok := netDevs[dev]; ok {
-			rxNow += rx
-			txNow += tx
+		// the interface moving the most bytes since the last update is active
+		if stat.rxDelta+stat.txDelta > busiest {
+			busiest, netActiveIface = stat.rxDelta+stat.txDelta, name
 		}
 	}
 
-	defer func() { rxOld, txOld = rxNow, txNow }()
+	defer func() { netSampled = time.Now() }()
+
+	var rxRate, txRate float64 = 0, 0
+
+	if elapsed := time.Since(netSampled).Seconds(); !netSampled.IsZero() && elapsed > 0 {
+		rxRate, txRate = float64(rxDelta)/elapsed, float64(txDelta)/elapsed
+		setMetric("gods_network_receive_bytes_per_second", rxRate)
+		setMetric("gods_network_transmit_bytes_per_second", txRate)
+	}
 
 	var download, upload string = " ", " "
 
-	if rxNow-rxOld != 0.0 {
-		download = "↓"
+	if *netDisplay == "rates" {
+		download = "↓" + formatRate(rxRate)
+		upload = " ↑" + formatRate(txRate)
+	} else {
+		// ignore background chatter below the threshold
+		if rxDelta != 0 && rxRate >= *netThreshold {
+			download = "↓"
+		}
+
+		if txDelta != 0 && txRate >= *netThreshold {
+			upload = "↑"
+		}
+	}
+
+	var label = netSign
+
+	if *netActive && netActiveIface != "" {
+		label += " " + netActiveIface
+	}
+
+	if firstSample("net") {
+		return label + " " + *firstPlaceholder
+	}
+
+	text := fmt.Sprintf("%s %s%s", label, download, upload)
+
+	if *netDisplay == "total" {
+		text = label + " " + formatRate(rxRate+txRate) + "/s"
 	}
 
-	if txNow-txOld != 0.0 {
-		upload = "↑"
+	if probe := connectivity.get(); probe != "" {
+		text += " " + probe
 	}
 
-	return fmt.Sprintf("%s %s%s", netSign, download, upload)
+	return text
 }
 
-// colored surrounds the percentage with color escapes if it is >= 70
-func colored(icon string, percentage int) string {
+// colorForPercent selects the dwm color escape for a percentage
+func colorForPercent(percentage int) string {
 	if percentage >= 100 {
-		return fmt.Sprintf("%s%3d", icon, percentage)
+		return colorCritical
 	} else if percentage >= 70 {
-		return fmt.Sprintf("%s%3d", icon, percentage)
+		return colorWarning
 	}
-	return fmt.Sprintf("%s%3d", icon, percentage)
+	return colorNormal
+}
+
+// colored surrounds the icon with the color escape of the percentage. Every
+// tier emits the same number of escapes and pads the value to three runes, so
+// the visible width never changes between tiers.
+func colored(icon string, percentage int) string {
+	return fmt.Sprintf("%s%s%s%3s", colorForPercent(percentage), icon, colorNormal, formatInt(percentage))
 }
 
 // updatePower reads the current battery and power plug status
 func updatePower() string {
-	const powerSupply = "/sys/class/power_supply"
-	var enFull, enNow, enPerc, curNow int = 0, 0, 0, 0
-	var plugged, err = ioutil.ReadFile(powerSupply + "/AC/online")
+	var enFull, enNow, enPerc, curNow, batteries int = 0, 0, 0, 0, 0
+	var wattHours float64 = 0
+	deleteMetric("gods_battery_percent")
 
-	if err != nil {
-		return "ÏERR"
+	if *powerBackend == "upower" {
+		return updateUPower()
 	}
 
-	batts, err := ioutil.ReadDir(powerSupply)
+	var supplies, err = parsePowerSupplies()
 
-	if err != nil {
-		return "ÏERR"
+	if os.IsNotExist(err) { // No power_supply class at all, e.g. in VMs and containers.
+		return noBattery(err)
+	} else if err != nil {
+		return "Ï" + errText(err)
 	}
 
-	for _, batt := range batts {
-		name := batt.Name()
-
+	for name, batteryValues := range supplies {
 		if !strings.HasPrefix(name, "BAT") {
 			continue
 		}
 
-		batteryValues := parseFile(powerSupply + "/" + batt.Name() + "/uevent")
+		batteries++
+
+		if *verbose {
+			log.Printf("%s uevent:\n%s", name, batteryValues)
+		}
+
+		battery := readBattery(batteryValues)
+
+		// without a full value the battery can't contribute to the percentage
+		if !battery.hasFull {
+			continue
+		}
+
+		wattHours += battery.wattHours()
+		enFull += battery.full
+		enNow += battery.now
+		curNow += battery.current
+	}
+
+	if batteries == 0 { // No battery hardware, e.g. on desktops.
+		return noBattery(errors.New("no battery"))
+	}
+
+	plugged, err := ioutil.ReadFile(powerSupply() + "/AC/online")
+
+	if err != nil {
+		return "Ï" + errText(err)
+	}
 
-		enFull += batteryValues.SearchForInt([]string{"POWER_SUPPLY_ENERGY_FULL", "POWER_SUPPLY_CHARGE_FULL"})
-		enNow += batteryValues.SearchForInt([]string{"POWER_SUPPLY_ENERGY_NOW", "POWR_SUPPLY_CHARGE_NOW"})
-		curNow += batteryValues.SearchForInt([]string{"POWER_SUPPLY_CURRENT_NOW", "POWER_SUPPLY_POWER_NOW"})
+	if enFull == 0 { // No battery with a readable full value, or all report zero.
+		return "Ï" + errText(errors.New("no battery reports its full capacity"))
 	}
 
-	if enFull == 0 { // Battery found but no readable full file.
-		return "ÏERR"
+	enPerc = percent(float64(enNow), float64(enFull))
+	var remaining float32 = 0
+
+	if plugged[0] != '1' && curNow != 0 {
+		remaining = float32(enNow) / float32(curNow)
 	}
 
-	enPerc = enNow * 100 / enFull
-	icon := unpluggedSign
+	return formatPower(enPerc, plugged[0] == '1', wattHours, remaining)
+}
+
+// formatPower renders the power segment from the charge level in percent, the
+// energy left and the hours until the batteries are empty, 0 if unknown or
+// charging
+func formatPower(enPerc int, plugged bool, wattHours float64, remaining float32) string {
+	setMetric("gods_battery_percent", float64(enPerc))
+	icon := batteryIcon(enPerc, plugged)
 	timeRemaining := ""
+	energy := ""
+
+	if *batteryEnergy {
+		energy = fmt.Sprintf(" (%sWh)", formatInt(int(math.Round(wattHours))))
+	}
 
-	if plugged[0] == '1' {
-		icon = pluggedSign
-	} else if curNow != 0 {
-		remaining := float32(enNow) / float32(curNow)
+	if remaining > 0 {
 		time_in_min := int(remaining * 60)
 		hours := time_in_min / 60
 		time_in_min -= hours * 60
@@ -145,37 +254,88 @@ func updatePower() string {
 		timeRemaining = fmt.Sprintf(" [%d:%02d]", hours, time_in_min)
 	}
 
-	if enPerc <= 5 {
-		return fmt.Sprintf("%s %3d%s", icon, enPerc, timeRemaining)
-	} else if enPerc <= 10 {
-		return fmt.Sprintf("%s %3d%s", icon, enPerc, timeRemaining)
+	switch *batteryTime {
+	case "never":
+		timeRemaining = ""
+	case "reserve":
+		// as wide as " [h:mm]", so plugging in doesn't shift the segments behind
+		timeRemaining = padField(timeRemaining, 7, 'l')
+	}
+
+	level := formatInt(enPerc)
+
+	if *batteryGauge > 0 {
+		level = gauge(enPerc, *batteryGauge)
+	}
+
+	if enPerc <= *batteryCritical {
+		// the whole segment, not only the icon, so it can't be overlooked
+		return fmt.Sprintf("%s%s%s %3s%s%s%s%s", *batteryBlink, colorCritical, icon, level, energy, timeRemaining, colorNormal, *batteryBlink)
+	} else if enPerc <= *batteryWarning {
+		return fmt.Sprintf("%s%s %s%3s%s%s", colorWarning, icon, colorNormal, level, energy, timeRemaining)
 	}
 
-	return fmt.Sprintf("%s %3d%s", icon, enPerc, timeRemaining)
+	return fmt.Sprintf("%s %3s%s%s", icon, level, energy, timeRemaining)
 }
 
-// updateCPUUse reads the last minute sysload and scales it to the core count
+// loadColumnIndex maps -load-column to the field index in /proc/loadavg
+func loadColumnIndex() int {
+	switch *loadColumn {
+	case 5:
+		return 1
+	case 15:
+		return 2
+	}
+	return 0
+}
+
+// updateCPUUse reads the sysload of the -load-column and scales it to the
+// core count
 func updateCPUUse() string {
-	var load float32
-	var loadavg, err = ioutil.ReadFile("/proc/loadavg")
+	var load [3]float32
+	deleteMetric("gods_cpu_percent")
+
+	var loadavg, err = ioutil.ReadFile(*procfsRoot + "/loadavg")
 
 	if err != nil {
-		return cpuSign + "ERR"
+		return cpuSign + errText(err)
 	}
 
-	_, err = fmt.Sscanf(string(loadavg), "%f", &load)
+	_, err = fmt.Sscanf(string(loadavg), "%f %f %f", &load[0], &load[1], &load[2])
 
 	if err != nil {
-		return cpuSign + "ERR"
+		return cpuSign + errText(err)
+	}
+	var percentage = percent(float64(load[loadColumnIndex()]), float64(cores))
+
+	setMetric("gods_cpu_percent", float64(percentage))
+
+	if *cpuHistory > 0 {
+		cpuRing.add(percentage, *cpuHistory)
+		return colorForPercent(percentage) + cpuSign + colorNormal + " " + sparkline(cpuRing.ordered())
+	}
+
+	if *cpuPercentile > 0 {
+		cpuWindowRing.add(percentage, *cpuWindow)
+		sustained := percentile(cpuWindowRing.ordered(), *cpuPercentile)
+
+		if *cpuPercentileOnly {
+			return colored(cpuSign, sustained)
+		}
+
+		return fmt.Sprintf("%s p%s:%s", colored(cpuSign, percentage), formatInt(*cpuPercentile), formatInt(sustained))
 	}
-	return colored(cpuSign, int(load*100.0/float32(cores)))
+
+	return colored(cpuSign, percentage)
 }
 
 // updateMemUse reads the memory used by applications and scales to [0, 100]
 func updateMemUse() string {
-	var file, err = os.Open("/proc/meminfo")
+	deleteMetric("gods_memory_percent")
+
+	var file, err = os.Open(*procfsRoot + "/meminfo")
 	if err != nil {
-		return memSign + "ERR"
+		return memSign + errText(err)
 	}
 	defer file.Close()
 
@@ -185,7 +345,7 @@ func updateMemUse() string {
 	for info := bufio.NewScanner(file); done != 15 && info.Scan(); {
 		var prop, val = "", 0
 		if _, err = fmt.Sscanf(info.Text(), "%s %d", &prop, &val); err != nil {
-			return memSign + "ERR"
+			return memSign + errText(err)
 		}
 		switch prop {
 		case "MemTotal:":
@@ -203,7 +363,11 @@ func updateMemUse() string {
 			done |= 8
 		}
 	}
-	return colored(memSign, used*100/total)
+	var percentage = percent(float64(used), float64(total))
+
+	setMetric("gods_memory_percent", float64(percentage))
+
+	return colored(memSign, percentage)
 }
 
 func getHostname() (hostname string) {
@@ -211,25 +375,245 @@ func getHostname() (hostname string) {
 		hostname = strings.TrimSpace(string(tmp))
 	}
 
+	if *shortHostname {
+		hostname = strings.SplitN(hostname, ".", 2)[0]
+	}
+
 	return
 }
 
-// main updates the dwm statusbar every second
+// updateDate formats the current local time
+func updateDate() string {
+	return time.Now().Local().Format(dateFormat)
+}
+
+// updateWeek shows the ISO week number, e.g. W34
+func updateWeek() string {
+	_, week := time.Now().Local().ISOWeek()
+	return fmt.Sprintf("W%d", week)
+}
+
+// updateYearDay shows the day of the year, e.g. D238
+func updateYearDay() string {
+	return fmt.Sprintf("D%d", time.Now().Local().YearDay())
+}
+
+// output sets the status line as X root window name or prints it to stdout
+func output(line string) {
+	outputTo(*outputMode, line)
+}
+
+// outputTo writes the status line to xsetroot, stdout, stdout as Waybar JSON,
+// the terminal preview or appends it to a file.
+// With -skip-unchanged a line equal to the last one of the sink is dropped.
+func outputTo(sink, line string) {
+	if *skipUnchanged {
+		if last, ok := lastOutput[sink]; ok && last == line {
+			return
+		}
+		lastOutput[sink] = line
+	}
+
+	switch sink {
+	case "stdout":
+		fmt.Println(line)
+	case "waybar":
+		fmt.Println(waybarLine(line))
+	case "preview":
+		previewLine(line)
+	case "xsetroot":
+		if err := exec.Command("xsetroot", "-name", line).Run(); err != nil {
+			xsetrootFailed++
+			delete(lastOutput, sink) // try the same line again with -skip-unchanged
+			log.Printf("xsetroot: %v (%d failures in a row)", err, xsetrootFailed)
+
+			if *xsetrootFailures > 0 && xsetrootFailed >= *xsetrootFailures {
+				log.Fatal("giving up on xsetroot")
+			}
+			return
+		}
+
+		xsetrootFailed = 0
+	default:
+		if err := appendLine(sink, line); err != nil {
+			log.Print(err)
+		}
+	}
+}
+
+// nextTick returns how long to sleep until the next update
+func nextTick(now time.Time) time.Duration {
+	var every, clock = tickInterval()
+
+	// a clock woken by -clock-wake is always aligned, or it would lag behind
+	if !*alignTicks && !clock {
+		return every
+	}
+
+	// sleep until beginning of next interval
+	return now.Truncate(every).Add(every).Sub(now)
+}
+
+// main updates the dwm statusbar every interval
 func main() {
+	flag.Parse()
+
+	if *compact {
+		*presetName = "compact"
+	}
+
+	if *preview {
+		*outputMode = "preview"
+	}
+
+	if err := applyPreset(*presetName); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := applyTheme(*themeName); err != nil {
+		log.Fatal(err)
+	}
+
+	if *netInterfaces != "" {
+		for _, name := range strings.Split(*netInterfaces, ",") {
+			netDevs[strings.TrimSpace(name)] = struct{}{}
+		}
+	}
+
+	errs, warnings := validateConfig()
+
+	for _, warning := range warnings {
+		log.Print("warning: ", warning)
+	}
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			log.Print("error: ", err)
+		}
+		os.Exit(2)
+	}
+
+	enabled, _ = selectSegments(*segmentList)
+	bars, _ = parseBars(*barList)
+
+	if *listInterfaces {
+		if err := printInterfaces(); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *listSensors {
+		if err := printSensors(); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *colorTest {
+		output(colorTestLine())
+		return
+	}
+
+	if *listBatteries {
+		if err := printBatteries(); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *weatherURL != "" {
+		// failed requests keep the last known value in the bar
+		startJob("weather", *weatherInterval, &weather, fetchWeather)
+	}
+
+	if *probeAddr != "" {
+		startJob("net", *probeInterval, &connectivity, probeConnectivity)
+	}
+
+	if isShown("failed") {
+		startJob("failed", *failedInterval, &failedUnits, fetchFailedUnits)
+	}
+
+	if isShown("containers") {
+		startJob("containers", *containersInterval, &containers, fetchContainers)
+	}
+
+	watchFiles()
+	loadZones()
+	fieldPads = parsePads(*padList)
+	collapseRules = parseCollapse(*collapseList)
+	segmentGroups = parseGroups(*groupList)
+
+	if *selftest {
+		os.Exit(selfTest(os.Stdout))
+	}
+
+	if *httpAddr != "" {
+		startHTTP()
+	}
+
+	if *pprofAddr != "" {
+		startPprof()
+	}
+
+	if *powerEvents {
+		watchPowerEvents()
+	}
+
+	if len(jobs) > 0 && *watchdogLimit > 0 {
+		go watchdog()
+	}
+
+	if *controlSocket != "" {
+		if err := listenControl(*controlSocket); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	handleSignals()
+	loopDone()
+	go sdWatchdog()
+	sdNotify("READY=1")
+
 	for {
-		var status = []string{
-			getHostname(),
-			updateNetUse(),
-			updateCPUUse(),
-			updateMemUse(),
-			updatePower(),
-			time.Now().Local().Format("Mon 02 " + dateSeparator + " 15:04:05"),
+		var start = time.Now()
+
+		if !isPaused() {
+			if len(bars) > 0 {
+				updateBars()
+			} else {
+				output(truncate(sanitize(statusLine()), *maxLength))
+			}
+
+			if *promFile != "" {
+				if err := writeProm(*promFile); err != nil {
+					log.Print(err)
+				}
+			}
+
+			if *statsdAddr != "" {
+				sendStatsd()
+			}
+		}
+
+		loopDone()
+
+		if *once {
+			return
 		}
-		exec.Command("xsetroot", "-name", strings.Join(status, fieldSeparator)).Run()
 
-		// sleep until beginning of next second
-		var now = time.Now()
+		var wait = nextTick(time.Now())
 
-		time.Sleep(now.Truncate(time.Second).Add(time.Second).Sub(now))
+		// don't fall further behind by waiting for the next aligned tick
+		if took := time.Since(start); took > *interval {
+			log.Printf("update took %v, longer than the interval of %v", took, *interval)
+			wait = 0
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-wake:
+		}
 	}
 }
@@ -0,0 +1,14 @@
+package main
+
+import "testing"
+
+// the segments behind a colored value must not shift when it changes tier
+func TestColoredWidth(t *testing.T) {
+	want := visibleLen(colored(cpuSign, 5))
+
+	for _, percentage := range []int{70, 100} {
+		if got := visibleLen(colored(cpuSign, percentage)); got != want {
+			t.Errorf("visibleLen(colored(%q, %d)) = %d, want %d", cpuSign, percentage, got, want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"sort"
+)
+
+var (
+	cpuHistory        = flag.Int("cpu-history", 0, "show the cpu segment as a sparkline of this many recent updates instead of the current value (disabled if <= 0)")
+	cpuPercentile     = flag.Int("cpu-percentile", 0, "also show this percentile of the cpu usage over the last -cpu-window updates, e.g. 95 (disabled if <= 0)")
+	cpuWindow         = flag.Int("cpu-window", 60, "count of updates the -cpu-percentile is computed over")
+	cpuPercentileOnly = flag.Bool("cpu-percentile-only", false, "show and color the cpu segment by the -cpu-percentile instead of the current value")
+)
+
+// sparks are the block glyphs of a sparkline from low to high
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// ring keeps the last values added to it
+type ring struct {
+	values []int
+	next   int
+	full   bool
+}
+
+var (
+	cpuRing       ring // percentages of the last -cpu-history updates
+	cpuWindowRing ring // percentages of the last -cpu-window updates
+)
+
+// add stores a value, replacing the oldest once size values are kept
+func (r *ring) add(value, size int) {
+	if len(r.values) != size {
+		r.values, r.next, r.full = make([]int, size), 0, false
+	}
+
+	r.values[r.next] = value
+	r.next = (r.next + 1) % size
+	r.full = r.full || r.next == 0
+}
+
+// ordered returns the kept values from the oldest to the newest
+func (r *ring) ordered() []int {
+	if !r.full {
+		return r.values[:r.next]
+	}
+
+	return append(append([]int{}, r.values[r.next:]...), r.values[:r.next]...)
+}
+
+// percentile returns the nearest-rank p-th percentile of values
+func percentile(values []int, p int) int {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := append([]int{}, values...)
+	sort.Ints(sorted)
+	rank := (p*len(sorted) + 99) / 100
+
+	if rank < 1 {
+		rank = 1
+	} else if rank > len(sorted) {
+		rank = len(sorted)
+	}
+
+	return sorted[rank-1]
+}
+
+// sparkline renders percentages as one block glyph each, clamping to [0, 100]
+func sparkline(percentages []int) string {
+	line := make([]rune, len(percentages))
+
+	for i, p := range percentages {
+		if p < 0 {
+			p = 0
+		} else if p > 100 {
+			p = 100
+		}
+
+		line[i] = sparks[p*(len(sparks)-1)/100]
+	}
+
+	return string(line)
+}
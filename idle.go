@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const idleSign = "IDLE"
+
+var (
+	afkAfter = flag.Duration("afk-after", 0, "idle time from which the idle segment shows AFK instead of the duration (disabled if 0)")
+	afkSign  = flag.String("afk-sign", "AFK", "shown by the idle segment after -afk-after")
+)
+
+// updateIdle shows how long the X session had no input, from xprintidle in
+// milliseconds. Outside of X the segment is omitted.
+func updateIdle() string {
+	if os.Getenv("DISPLAY") == "" {
+		return ""
+	}
+
+	out, err := runCommand("xprintidle")
+
+	if err != nil {
+		return idleSign + " " + errText(err)
+	}
+
+	ms, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+
+	if err != nil {
+		return idleSign + " " + errText(err)
+	}
+
+	idle := time.Duration(ms) * time.Millisecond
+
+	if *afkAfter > 0 && idle >= *afkAfter {
+		return *afkSign
+	}
+
+	return idleSign + " " + formatDuration(idle)
+}
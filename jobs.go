@@ -0,0 +1,112 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// maxBackoff caps the retry delay of failing network segments
+const maxBackoff = time.Hour
+
+// backoff computes the delay until the next attempt of a periodic task. After
+// consecutive failures the delay doubles, up to max, and a success resets it
+// to the normal interval.
+type backoff struct {
+	interval time.Duration // delay after a success
+	max      time.Duration // upper bound of the delay after failures
+	delay    time.Duration // current delay
+}
+
+// next records the outcome of an attempt and returns the time to wait
+func (b *backoff) next(err error) time.Duration {
+	switch {
+	case err == nil:
+		b.delay = b.interval
+	case b.delay < b.interval:
+		b.delay = 2 * b.interval
+	default:
+		b.delay *= 2
+	}
+
+	if b.delay > b.max {
+		b.delay = b.max
+	}
+
+	return b.delay
+}
+
+// job is a segment update running periodically on its own goroutine
+type job struct {
+	segment  string // name of the segment showing the result
+	update   func() error
+	interval time.Duration
+	out      *cache        // receives the stuck state, see watchdog
+	kick     chan struct{} // interrupts the wait for the next attempt
+
+	sync.Mutex
+	reported   time.Time     // end of the last attempt, successful or not
+	delay      time.Duration // wait after the last attempt
+	generation int           // incremented when the watchdog restarts the job
+}
+
+// jobs are all background updates started by startJob
+var jobs []*job
+
+// startJob calls update every interval forever, backing off while it fails.
+// It is meant to be used by every network-backed segment. With -selftest the
+// job is only registered, the self test runs it once itself.
+func startJob(segment string, interval time.Duration, out *cache, update func() error) {
+	j := &job{segment: segment, update: update, interval: interval, out: out, kick: make(chan struct{}, 1), reported: time.Now(), delay: interval}
+	jobs = append(jobs, j)
+
+	if *staleAfter > 0 {
+		out.Lock()
+		out.maxAge = time.Duration(*staleAfter) * interval
+		out.Unlock()
+	}
+
+	if !*selftest {
+		go j.poll(0)
+	}
+}
+
+// poll runs the job until the watchdog replaces this generation
+func (j *job) poll(generation int) {
+	b := backoff{interval: j.interval, max: maxBackoff}
+
+	if b.max < j.interval {
+		b.max = j.interval
+	}
+
+	for {
+		var err error
+
+		// paused bars don't need fresh values
+		if !isPaused() {
+			err = j.update()
+		}
+
+		delay := b.next(err)
+
+		j.Lock()
+		if j.generation != generation {
+			j.Unlock()
+			return
+		}
+		j.reported, j.delay = time.Now(), delay
+		j.Unlock()
+
+		select {
+		case <-time.After(delay):
+		case <-j.kick:
+		}
+	}
+}
+
+// refresh makes the job update now instead of waiting for its next attempt
+func (j *job) refresh() {
+	select {
+	case j.kick <- struct{}{}:
+	default: // a refresh is already pending
+	}
+}
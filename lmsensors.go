@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"sort"
+	"strings"
+)
+
+var sensorsBackend = flag.String("sensors-backend", "sysfs", "source of the hwmon sensors: sysfs or lm-sensors (runs sensors -j, "+
+	"then -temp-labels may name chip/feature, e.g. coretemp/Package id 0)")
+
+// sensorReading is the current value of a sensor, in °C for temperatures and
+// rpm for fans
+type sensorReading struct {
+	chip, label string
+	value       float64
+}
+
+// matches tells whether a -temp-labels entry selects the reading, either by
+// its label or as chip/label with a prefix of the chip name
+func (r sensorReading) matches(name string) bool {
+	if slash := strings.LastIndex(name, "/"); slash >= 0 {
+		return strings.HasPrefix(r.chip, name[:slash]) && r.label == name[slash+1:]
+	}
+
+	return r.label == name
+}
+
+// readSensors returns the current values of all sensors of a kind, e.g.
+// "temp" or "fan", from the -sensors-backend. Unreadable sysfs inputs are
+// skipped.
+func readSensors(kind string) ([]sensorReading, error) {
+	if *sensorsBackend == "lm-sensors" {
+		return lmSensors(kind)
+	}
+
+	var readings []sensorReading
+
+	for _, s := range hwmonSensors(kind) {
+		var value float64
+		var err error
+
+		if kind == "fan" {
+			var rpm int
+			rpm, err = readInt(s.input)
+			value = float64(rpm)
+		} else {
+			value, err = readMilli(s.input)
+		}
+
+		if err == nil {
+			readings = append(readings, sensorReading{s.chip, s.label, value})
+		}
+	}
+
+	return readings, nil
+}
+
+// lmSensors parses the output of sensors -j, which maps chips to features to
+// subfeatures like temp1_input, into the readings of a kind sorted by chip and
+// feature
+func lmSensors(kind string) ([]sensorReading, error) {
+	out, err := runCommand("sensors", "-j")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var chips map[string]map[string]json.RawMessage
+
+	if err := json.Unmarshal(out, &chips); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(chips))
+
+	for chip := range chips {
+		names = append(names, chip)
+	}
+
+	sort.Strings(names)
+
+	var readings []sensorReading
+
+	for _, chip := range names {
+		features := make([]string, 0, len(chips[chip]))
+
+		for feature := range chips[chip] {
+			features = append(features, feature)
+		}
+
+		sort.Strings(features)
+
+		for _, feature := range features {
+			// "Adapter" is a plain string, the features are objects
+			var subfeatures map[string]float64
+
+			if json.Unmarshal(chips[chip][feature], &subfeatures) != nil {
+				continue
+			}
+
+			for name, value := range subfeatures {
+				if strings.HasPrefix(name, kind) && strings.HasSuffix(name, "_input") {
+					readings = append(readings, sensorReading{chip, feature, value})
+				}
+			}
+		}
+	}
+
+	return readings, nil
+}
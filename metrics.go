@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	promFile = flag.String("prom-file", "", "write the metrics to this file for the node_exporter textfile collector each interval (disabled if empty)")
+
+	// metrics are the numbers behind the segments of the last update
+	metrics = struct {
+		sync.Mutex
+		values map[string]float64
+	}{values: make(map[string]float64)}
+)
+
+// setMetric records the current value of a gauge
+func setMetric(name string, value float64) {
+	metrics.Lock()
+	metrics.values[name] = value
+	metrics.Unlock()
+}
+
+// deleteMetric drops gauges, so a segment which fails or is omitted doesn't
+// keep exporting its last value. Updaters call it before they read, then set
+// the gauges again on success.
+func deleteMetric(names ...string) {
+	metrics.Lock()
+	for _, name := range names {
+		delete(metrics.values, name)
+	}
+	metrics.Unlock()
+}
+
+// metricNames returns the names of all recorded gauges in a stable order
+func metricNames() []string {
+	metrics.Lock()
+	defer metrics.Unlock()
+
+	names := make([]string, 0, len(metrics.values))
+
+	for name := range metrics.values {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// getMetric returns the current value of a gauge
+func getMetric(name string) (float64, bool) {
+	metrics.Lock()
+	defer metrics.Unlock()
+
+	value, ok := metrics.values[name]
+
+	return value, ok
+}
+
+// writeProm writes all gauges in the Prometheus text format. The file is
+// replaced atomically, so the collector never reads a partial file.
+func writeProm(path string) error {
+	var text strings.Builder
+
+	for _, name := range metricNames() {
+		value, _ := getMetric(name)
+		fmt.Fprintf(&text, "# TYPE %s gauge\n%s %g\n", name, name, value)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".gods-*.prom")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(text.String()); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
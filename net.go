@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	linkSign = "LINK"
+	gwSign   = "GW"
+	connSign = "CONN"
+)
+
+var (
+	netInterfaces  = flag.String("net-interfaces", "", "comma separated network interfaces summed up in the net segment (all with a device in sysfs if empty)")
+	netDisplay     = flag.String("net-display", "arrows", "traffic shown in the net segment: arrows, rates or total (download and upload summed)")
+	netThreshold   = flag.Float64("net-threshold", 1024, "bytes per second below which the net arrows stay blank")
+	netRateFormat  = flag.String("net-rate-format", "auto", "scaling of the net rates: auto, fixed-unit (always -net-rate-unit) or fixed-width (auto padded to the widest value)")
+	netRateUnit    = flag.String("net-rate-unit", "MB", "unit of the net rates with -net-rate-format fixed-unit, one of the -rate-byte-base units, e.g. KB or MiB")
+	netActive      = flag.Bool("net-active", false, "label the net segment with the interface moving the most bytes")
+	listInterfaces = flag.Bool("list-interfaces", false, "print the network interfaces with their byte totals and exit")
+)
+
+var (
+	ifaceStats     = map[string]*ifaceStat{} // counters of every interface present at the last update
+	netActiveIface string                    // interface which moved the most bytes recently
+)
+
+// formatRate renders a rate in bytes per second as set by -net-rate-format
+func formatRate(n float64) string {
+	switch *netRateFormat {
+	case "fixed-unit":
+		return formatBytesIn(n, *rateByteBase, *netRateUnit)
+	case "fixed-width":
+		// the widest values are just below the base, e.g. 999.9KB or 1023.9KiB,
+		// as formatBytes rounds larger ones up to the next unit
+		widest := formatFloat(float64(*rateByteBase-1)) + units(*rateByteBase)[1]
+		return padField(formatBytes(n, *rateByteBase), visibleLen(widest), 'r')
+	}
+
+	return formatBytes(n, *rateByteBase)
+}
+
+// ifaceStat tracks the counters of a single interface between updates
+type ifaceStat struct {
+	rx, tx           int  // totals at the last update
+	rxDelta, txDelta int  // bytes moved between the last two updates
+	physical         bool // backed by a device, unlike lo, bridges, veth, tun, wg, …
+}
+
+// trackInterfaces updates the per interface deltas with fresh totals. New
+// interfaces start with a zero delta, interfaces which vanished are dropped
+// and counters which went backwards, e.g. after reloading a driver, count
+// from zero.
+func trackInterfaces(ifaces []ifaceCounters) {
+	present := make(map[string]struct{}, len(ifaces))
+
+	for _, iface := range ifaces {
+		present[iface.name] = struct{}{}
+		stat, ok := ifaceStats[iface.name]
+
+		if !ok {
+			ifaceStats[iface.name] = &ifaceStat{rx: iface.rx, tx: iface.tx, physical: hasDevice(iface.name)}
+			continue
+		}
+
+		stat.rxDelta, stat.txDelta = counterDelta(stat.rx, iface.rx), counterDelta(stat.tx, iface.tx)
+		stat.rx, stat.tx = iface.rx, iface.tx
+	}
+
+	for name := range ifaceStats {
+		if _, ok := present[name]; !ok {
+			delete(ifaceStats, name)
+		}
+	}
+}
+
+// hasDevice tells whether a network interface is backed by hardware
+func hasDevice(iface string) bool {
+	_, err := os.Stat(*sysfsRoot + "/class/net/" + iface + "/device")
+
+	return err == nil
+}
+
+// summedInterface tells whether the net segment counts the traffic of an
+// interface. Without -net-interfaces only physical ones are, so traffic of
+// containers and VPNs isn't counted again on its way through a bridge or
+// tunnel.
+func summedInterface(name string, stat *ifaceStat) bool {
+	if len(netDevs) == 0 {
+		return stat.physical
+	}
+
+	_, ok := netDevs[name]
+
+	return ok
+}
+
+// counterDelta returns the increase of a counter, which restarts from zero
+// when it got reset
+func counterDelta(old, now int) int {
+	if now < old {
+		return now
+	}
+
+	return now - old
+}
+
+// ifaceCounters are the byte totals of a network interface
+type ifaceCounters struct {
+	name   string
+	rx, tx int
+}
+
+// readNetDev parses the byte totals of all interfaces in /proc/net/dev
+func readNetDev() ([]ifaceCounters, error) {
+	file, err := os.Open(*procfsRoot + "/net/dev")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	var void = 0 // target for unused values
+	var ifaces []ifaceCounters
+
+	for scanner := bufio.NewScanner(file); scanner.Scan(); {
+		var iface ifaceCounters
+
+		// large counters are not separated from the name by spaces
+		_, err = fmt.Sscanf(
+			strings.Replace(scanner.Text(), ":", " ", 1),
+			"%s %d %d %d %d %d %d %d %d %d",
+			&iface.name, &iface.rx, &void, &void, &void, &void, &void, &void, &void, &iface.tx,
+		)
+
+		// the two header lines don't parse
+		if err == nil {
+			ifaces = append(ifaces, iface)
+		}
+	}
+
+	return ifaces, nil
+}
+
+// printInterfaces lists the interfaces for configuring -net-interfaces
+func printInterfaces() error {
+	ifaces, err := readNetDev()
+
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "INTERFACE\tRX\tTX")
+
+	for _, iface := range ifaces {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", iface.name, formatBytes(float64(iface.rx), *byteBase), formatBytes(float64(iface.tx), *byteBase))
+	}
+
+	return w.Flush()
+}
+
+// route is an IPv4 route from /proc/net/route
+type route struct {
+	iface                string
+	destination, gateway uint32 // as printed by the kernel, in host byte order
+	flags, metric        int
+}
+
+// defaultRoute returns the active default route with the lowest metric
+func defaultRoute() (route, bool, error) {
+	file, err := os.Open(*procfsRoot + "/net/route")
+
+	if err != nil {
+		return route{}, false, err
+	}
+
+	defer file.Close()
+
+	var best route
+	var found = false
+
+	for scanner := bufio.NewScanner(file); scanner.Scan(); {
+		// Iface Destination Gateway Flags RefCnt Use Metric Mask MTU Window IRTT
+		fields := strings.Fields(scanner.Text())
+
+		if len(fields) < 7 {
+			continue
+		}
+
+		destination, err1 := strconv.ParseUint(fields[1], 16, 32)
+		gateway, err2 := strconv.ParseUint(fields[2], 16, 32)
+		flags, err3 := strconv.ParseInt(fields[3], 16, 32)
+		metric, err4 := strconv.Atoi(fields[6])
+
+		// the header line doesn't parse, 0x1 is RTF_UP
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil || destination != 0 || flags&0x1 == 0 {
+			continue
+		}
+
+		if !found || metric < best.metric {
+			best = route{fields[0], uint32(destination), uint32(gateway), int(flags), metric}
+			found = true
+		}
+	}
+
+	return best, found, nil
+}
+
+// linkType classifies an interface by its sysfs entry and name
+func linkType(iface string) string {
+	if _, err := os.Stat(*sysfsRoot + "/class/net/" + iface + "/wireless"); err == nil {
+		return "wifi"
+	}
+
+	switch {
+	case strings.HasPrefix(iface, "ww"):
+		return "wwan"
+	case strings.HasPrefix(iface, "tun"), strings.HasPrefix(iface, "tap"), strings.HasPrefix(iface, "wg"):
+		return "vpn"
+	}
+
+	return "eth"
+}
+
+// updateLink shows the type of the interface carrying the default route
+func updateLink() string {
+	def, found, err := defaultRoute()
+
+	if err != nil {
+		return linkSign + " " + errText(err)
+	}
+
+	if !found {
+		return linkSign + " none"
+	}
+
+	return linkSign + " " + linkType(def.iface)
+}
+
+// updateGateway shows the next hop of the default route
+func updateGateway() string {
+	def, found, err := defaultRoute()
+
+	if err != nil {
+		return gwSign + " " + errText(err)
+	}
+
+	// no default route or one without a gateway, like on point-to-point links
+	if !found || def.gateway == 0 {
+		return gwSign + " -"
+	}
+
+	// the kernel prints the address in host byte order, i.e. reversed on x86
+	gw := def.gateway
+
+	return gwSign + " " + net.IPv4(byte(gw), byte(gw>>8), byte(gw>>16), byte(gw>>24)).String()
+}
+
+// countEstablished counts the connections of a /proc/net/tcp table in the
+// ESTABLISHED state
+func countEstablished(path string) (int, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer file.Close()
+
+	count := 0
+
+	for scanner := bufio.NewScanner(file); scanner.Scan(); {
+		// sl local_address rem_address st tx_queue:rx_queue ...
+		fields := strings.Fields(scanner.Text())
+
+		// 01 is TCP_ESTABLISHED, the header line has "st" there
+		if len(fields) > 3 && fields[3] == "01" {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// updateConnections shows the number of established TCP connections over
+// IPv4 and IPv6. A missing table, e.g. without IPv6, counts as empty.
+func updateConnections() string {
+	v4, err4 := countEstablished(*procfsRoot + "/net/tcp")
+	v6, err6 := countEstablished(*procfsRoot + "/net/tcp6")
+
+	if err4 != nil && err6 != nil {
+		return connSign + " " + errText(err4)
+	}
+
+	return connSign + " " + formatInt(v4+v6)
+}
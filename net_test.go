@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+// fixed-width rates keep the width of the widest value, which is just below
+// the base in any unit
+func TestFormatRateFixedWidth(t *testing.T) {
+	defer func(format string, base int) { *netRateFormat, *rateByteBase = format, base }(*netRateFormat, *rateByteBase)
+
+	*netRateFormat = "fixed-width"
+
+	tests := []struct {
+		base  int
+		width int
+		rates []float64
+	}{
+		{1000, len("999.9KB"), []float64{0, 999, 1000, 999949, 999999, 1e6, 999.99e6}},
+		{1024, len("1023.9KiB"), []float64{0, 1023, 1024, 1023.94 * 1024, 1023.99 * 1024, 1 << 20}},
+	}
+
+	for _, test := range tests {
+		*rateByteBase = test.base
+
+		for _, rate := range test.rates {
+			if got := formatRate(rate); visibleLen(got) != test.width {
+				t.Errorf("base %d: formatRate(%v) = %q, want %d runes", test.base, rate, got, test.width)
+			}
+		}
+	}
+}
+
+func TestSummedInterface(t *testing.T) {
+	defer func(root string, stats map[string]*ifaceStat) { *sysfsRoot, ifaceStats = root, stats }(*sysfsRoot, ifaceStats)
+
+	*sysfsRoot, ifaceStats = "testdata/sys", map[string]*ifaceStat{}
+	trackInterfaces([]ifaceCounters{{name: "lo"}, {name: "enp0s25"}, {name: "wlp4s0"}, {name: "docker0"}})
+
+	want := map[string]bool{"lo": false, "enp0s25": true, "wlp4s0": true, "docker0": false}
+
+	for name, summed := range want {
+		if got := summedInterface(name, ifaceStats[name]); got != summed {
+			t.Errorf("summedInterface(%q) = %v, want %v", name, got, summed)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// loopProgress holds the time the status loop last finished an update
+var loopProgress struct {
+	sync.Mutex
+	last time.Time
+}
+
+// sdNotify sends a state like "READY=1" to systemd. It does nothing if gods
+// was not started by systemd with Type=notify or a watchdog.
+func sdNotify(state string) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+
+	if socket == "" {
+		return
+	}
+
+	// abstract sockets are announced with a leading "@"
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+
+	if err != nil {
+		return
+	}
+
+	defer conn.Close()
+
+	conn.Write([]byte(state))
+}
+
+// loopDone records that the status loop finished an update
+func loopDone() {
+	loopProgress.Lock()
+	loopProgress.last = time.Now()
+	loopProgress.Unlock()
+}
+
+// loopStalled tells whether the status loop missed two updates, allowing for
+// one command running into -exec-timeout
+func loopStalled() bool {
+	every, _ := tickInterval()
+
+	loopProgress.Lock()
+	defer loopProgress.Unlock()
+
+	return time.Since(loopProgress.last) > 2*every+*execTimeout
+}
+
+// sdWatchdog pings the systemd watchdog at half of WatchdogSec= as long as the
+// status loop makes progress, independent of how long it sleeps between
+// updates. It returns at once if the watchdog is not configured for gods.
+func sdWatchdog() {
+	usec, err := strconv.Atoi(os.Getenv("WATCHDOG_USEC"))
+
+	if err != nil || usec <= 0 {
+		return
+	}
+
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return
+	}
+
+	for range time.Tick(time.Duration(usec) * time.Microsecond / 2) {
+		if !loopStalled() {
+			sdNotify("WATCHDOG=1")
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"flag"
+	"strings"
+)
+
+var playIcons = flag.String("play-icons", "Playing=▶,Paused=⏸,Stopped=⏹", "icons of the MPRIS player states as status=icon list")
+
+// updatePlayState shows an icon for the state of the current MPRIS player.
+// Without a player the segment is omitted.
+func updatePlayState() string {
+	out, err := runCommand("playerctl", "status")
+
+	if err != nil {
+		return ""
+	}
+
+	status := strings.TrimSpace(string(out))
+
+	if icon, ok := lookup(parsePairs(*playIcons), status); ok {
+		return icon
+	}
+
+	return status
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+var (
+	batteryEnergy = flag.Bool("battery-energy", false, "show the energy left in the batteries in watt-hours")
+	listBatteries = flag.Bool("list-batteries", false, "print the power_supply devices with their type and uevent fields and exit")
+
+	batteryWarning  = flag.Int("battery-warning", 10, "battery percentage at or below which the icon gets the warning color")
+	batteryCritical = flag.Int("battery-critical", 5, "battery percentage at or below which the whole segment gets the critical color")
+	batteryGauge    = flag.Int("battery-gauge", 0, "show the battery level as a gauge of this many blocks instead of the percentage (disabled if <= 0)")
+	batteryBlink    = flag.String("battery-blink", "", "text placed around a critical battery segment for a blink patch to pick up")
+	powerBackend    = flag.String("power-backend", "sysfs", "source of the power segment: sysfs or upower (runs upower -i for the display device)")
+	batteryTime     = flag.String("battery-time", "auto", "time left in the power segment: auto (while discharging), reserve (keep its width when hidden) or never")
+	noBatteryMode   = flag.String("no-battery", "omit", "power segment without a battery: omit, label (show -no-battery-label) or err")
+	noBatteryLabel  = flag.String("no-battery-label", pluggedSign, "power segment shown by -no-battery label")
+	batteryIcons    = flag.String("battery-icons", "", "battery icons as level=icon list, each used up to its percentage, and charging=icon, "+
+		"e.g. 25=▁,50=▃,75=▅,100=▇,charging=⚡ (the AC and battery signs if empty)")
+)
+
+const (
+	upsSign   = "UPS"
+	mouseSign = "MOUSE"
+)
+
+// noBattery returns the power segment of machines without a battery as set by
+// -no-battery
+func noBattery(err error) string {
+	switch *noBatteryMode {
+	case "label":
+		return *noBatteryLabel
+	case "err":
+		return "Ï" + errText(err)
+	}
+
+	return ""
+}
+
+// updateUPower renders the power segment from the upower display device, which
+// combines all batteries like the sysfs backend does
+func updateUPower() string {
+	out, err := runCommand("upower", "-i", "/org/freedesktop/UPower/devices/DisplayDevice")
+
+	if err != nil {
+		return "Ï" + errText(err)
+	}
+
+	// "  percentage:          84%", "  time to empty:       3.2 hours"
+	fields := map[string]string{}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		if colon := strings.Index(line, ":"); colon >= 0 {
+			fields[strings.TrimSpace(line[:colon])] = strings.TrimSpace(line[colon+1:])
+		}
+	}
+
+	if fields["present"] == "no" || fields["percentage"] == "" {
+		return noBattery(errors.New("upower reports no battery"))
+	}
+
+	level, err := strconv.ParseFloat(strings.TrimSuffix(fields["percentage"], "%"), 64)
+
+	if err != nil {
+		return "Ï" + errText(err)
+	}
+
+	wattHours, _ := strconv.ParseFloat(strings.TrimSuffix(fields["energy"], " Wh"), 64)
+	state := fields["state"]
+	plugged := state == "charging" || state == "fully-charged" || state == "pending-charge"
+	var remaining float32 = 0
+
+	if empty := strings.Fields(fields["time to empty"]); !plugged && len(empty) == 2 {
+		if value, err := strconv.ParseFloat(empty[0], 64); err == nil {
+			remaining = float32(value * upowerHours[empty[1]])
+		}
+	}
+
+	return formatPower(percent(level, 100), plugged, wattHours, remaining)
+}
+
+// upowerHours converts the units of upower times to hours
+var upowerHours = map[string]float64{"seconds": 1.0 / 3600, "minutes": 1.0 / 60, "hours": 1, "days": 24}
+
+// batteryIcon selects the -battery-icons entry for the charge level, the one
+// with the lowest level not below it
+func batteryIcon(percentage int, plugged bool) string {
+	icons := parsePairs(*batteryIcons)
+
+	if plugged {
+		if icon, ok := lookup(icons, "charging"); ok {
+			return icon
+		}
+		return pluggedSign
+	}
+
+	best, found, icon := 0, false, unpluggedSign
+
+	for _, p := range icons {
+		if level, err := strconv.Atoi(p.key); err == nil && level >= percentage && (!found || level < best) {
+			best, found, icon = level, true, p.value
+		}
+	}
+
+	return icon
+}
+
+// batteryReading holds the values of a battery uevent with the variants of
+// the field names resolved. Batteries report either energy (µWh, µW) or
+// charge (µAh, µA); the first present field wins:
+//
+//	full:     ENERGY_FULL, CHARGE_FULL
+//	now:      ENERGY_NOW, CHARGE_NOW
+//	current:  CURRENT_NOW, POWER_NOW
+//	voltage:  VOLTAGE_NOW (µV)
+//	capacity: CAPACITY (percent as computed by the kernel)
+//	status:   STATUS, e.g. "Charging" or "Discharging"
+type batteryReading struct {
+	full, now, current, voltage, capacity int
+	status                                string
+	hasFull                               bool // full was present and parsed, which is needed for a percentage
+	hasEnergy                             bool // full and now are energies rather than charges
+}
+
+// wattHours returns the energy left in the battery. Charge based batteries
+// are converted with their current voltage.
+func (b batteryReading) wattHours() float64 {
+	if b.hasEnergy {
+		return float64(b.now) / 1e6
+	}
+
+	return float64(b.now) / 1e6 * float64(b.voltage) / 1e6
+}
+
+// readBattery resolves all battery values of a parsed uevent file
+func readBattery(h *Hash) batteryReading {
+	full, hasFull := h.SearchForIntOK([]string{"POWER_SUPPLY_ENERGY_FULL", "POWER_SUPPLY_CHARGE_FULL"})
+	_, hasEnergy := h.GetIntOK("POWER_SUPPLY_ENERGY_NOW")
+
+	return batteryReading{
+		full:      full,
+		hasFull:   hasFull,
+		hasEnergy: hasEnergy,
+		now:       h.SearchForInt([]string{"POWER_SUPPLY_ENERGY_NOW", "POWER_SUPPLY_CHARGE_NOW"}),
+		current:   h.SearchForInt([]string{"POWER_SUPPLY_CURRENT_NOW", "POWER_SUPPLY_POWER_NOW"}),
+		voltage:   h.SearchForInt([]string{"POWER_SUPPLY_VOLTAGE_NOW"}),
+		capacity:  h.SearchForInt([]string{"POWER_SUPPLY_CAPACITY"}),
+		status:    h.SearchForString([]string{"POWER_SUPPLY_STATUS"}),
+	}
+}
+
+// sortedSupplies returns the device names of the parsed power supplies in a
+// stable order
+func sortedSupplies(supplies map[string]*Hash) []string {
+	names := make([]string, 0, len(supplies))
+
+	for name := range supplies {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// updateUPS reads the charge and line status of the first UPS, if there is one
+func updateUPS() string {
+	supplies, err := parsePowerSupplies()
+
+	if err != nil {
+		return ""
+	}
+
+	for _, name := range sortedSupplies(supplies) {
+		if supplyType(name) != "UPS" {
+			continue
+		}
+
+		ups := readBattery(supplies[name])
+		line := "AC"
+
+		if ups.status == "Discharging" {
+			line = "BATT"
+		}
+
+		return fmt.Sprintf("%s %s %s", upsSign, formatInt(ups.capacity), line)
+	}
+
+	return ""
+}
+
+// updateHIDBattery reads the charge of wireless mice, keyboards, … which the
+// kernel exposes as "hid-*" batteries. Without such a device it is omitted.
+func updateHIDBattery() string {
+	supplies, err := parsePowerSupplies()
+
+	if err != nil {
+		return ""
+	}
+
+	var charges []string
+
+	for _, name := range sortedSupplies(supplies) {
+		if !strings.HasPrefix(name, "hid") || supplyType(name) != "Battery" {
+			continue
+		}
+
+		charges = append(charges, formatInt(readBattery(supplies[name]).capacity))
+	}
+
+	if len(charges) == 0 {
+		return ""
+	}
+
+	return mouseSign + " " + strings.Join(charges, " ")
+}
+
+// printBatteries lists every power_supply device with the fields gods can
+// read from it
+func printBatteries() error {
+	supplies, err := parsePowerSupplies()
+
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "DEVICE\tTYPE\tFIELDS")
+
+	for _, name := range sortedSupplies(supplies) {
+		fields := supplies[name].Fields()
+
+		for i, field := range fields {
+			fields[i] = strings.TrimPrefix(field, "POWER_SUPPLY_")
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, supplyType(name), strings.Join(fields, " "))
+	}
+
+	return w.Flush()
+}
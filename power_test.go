@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestUpdatePower(t *testing.T) {
+	defer func(root string) { *sysfsRoot = root }(*sysfsRoot)
+
+	// each case is a sysfs root below testdata/power
+	tests := []struct {
+		root, want string
+	}{
+		{"charging", "AC  80"},
+		{"discharging", "BAT  50 [2:30]"},
+		{"full", "AC 100"},
+		{"dual", "BAT  37 [3:00]"},   // 30 of 80 Wh at 10 W over BAT0 and BAT1
+		{"charge", "BAT  25 [2:00]"}, // CHARGE_* and CURRENT_NOW only
+	}
+
+	for _, test := range tests {
+		*sysfsRoot = "testdata/power/" + test.root
+
+		if got := updatePower(); got != test.want {
+			t.Errorf("%s: updatePower() = %q, want %q", test.root, got, test.want)
+		}
+	}
+}
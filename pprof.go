@@ -0,0 +1,18 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	_ "net/http/pprof" // registers /debug/pprof on http.DefaultServeMux
+)
+
+var pprofAddr = flag.String("pprof", "", "serve net/http/pprof at this address for profiling, e.g. localhost:6060 (disabled if empty)")
+
+// startPprof serves the profiles in the background. The handlers live on the
+// default mux, which -http doesn't use, so they are only reachable here.
+func startPprof() {
+	go func() {
+		log.Fatal(http.ListenAndServe(*pprofAddr, nil))
+	}()
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+var (
+	presetName = flag.String("preset", "default", "named set of labels and separators: default or compact")
+	compact    = flag.Bool("compact", false, "shorthand for -preset compact, for tiny screens")
+	dateLayout = flag.String("date-format", "", "Go time layout of the date segment, e.g. \"Mon 02 | 15:04\" (the one of the -preset if empty)")
+
+	squeeze bool // drop all spaces from the segments
+)
+
+// preset is a named replacement of the labels and separators
+type preset struct {
+	unplugged, plugged, cpu, mem, net string
+	dateSeparator, fieldSeparator     string
+	dateFormat                        string
+	squeeze                           bool
+}
+
+var presets = map[string]preset{
+	"default": {
+		unplugged: "BAT", plugged: "AC", cpu: "CPU", mem: "MEM", net: "NET",
+		dateSeparator: "|", fieldSeparator: " | ",
+		dateFormat: "Mon 02 | 15:04:05",
+	},
+	"compact": {
+		unplugged: "B", plugged: "A", cpu: "C", mem: "M", net: "N",
+		dateSeparator: "|", fieldSeparator: "|",
+		dateFormat: "02|15:04",
+		squeeze:    true,
+	},
+}
+
+// applyPreset replaces the labels and separators with those of a preset. A
+// -date-format takes precedence over the date format of the preset.
+func applyPreset(name string) error {
+	p, ok := presets[name]
+
+	if !ok {
+		return fmt.Errorf("unknown preset %q", name)
+	}
+
+	unpluggedSign, pluggedSign = p.unplugged, p.plugged
+	cpuSign, memSign, netSign = p.cpu, p.mem, p.net
+	dateSeparator, fieldSeparator = p.dateSeparator, p.fieldSeparator
+	dateFormat, squeeze = p.dateFormat, p.squeeze
+
+	if *dateLayout != "" {
+		dateFormat = *dateLayout
+	}
+
+	return nil
+}
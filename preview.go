@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+var preview = flag.Bool("preview", false, "show the status line in the terminal with ANSI colors, refreshed in place, instead of setting it in dwm (same as -output preview)")
+
+// previewStarted is set once the screen was cleared for the first line
+var previewStarted bool
+
+// ansi translates the dwm color escapes into ANSI SGR sequences: normal resets,
+// warning is yellow, critical red and other indices get a bright color each
+func ansi(line string) string {
+	var text strings.Builder
+
+	for _, r := range line {
+		switch {
+		case r >= ' ' || r == '\t':
+			text.WriteRune(r)
+		case string(r) == colorNormal:
+			text.WriteString("\x1b[0m")
+		case string(r) == colorCritical:
+			text.WriteString("\x1b[31m")
+		case string(r) == colorWarning:
+			text.WriteString("\x1b[33m")
+		default:
+			fmt.Fprintf(&text, "\x1b[%dm", 90+int(r)%8)
+		}
+	}
+
+	return text.String() + "\x1b[0m"
+}
+
+// previewLine draws the status line at the top of the terminal, replacing the
+// previous one
+func previewLine(line string) {
+	if !previewStarted {
+		fmt.Print("\x1b[H\x1b[2J")
+		previewStarted = true
+	}
+
+	fmt.Print("\x1b[H" + ansi(line) + "\x1b[K")
+}
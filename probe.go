@@ -0,0 +1,31 @@
+package main
+
+import (
+	"flag"
+	"net"
+	"time"
+)
+
+var (
+	probeAddr     = flag.String("probe", "", "host:port dialed over TCP to check connectivity, e.g. 1.1.1.1:53 (disabled if empty)")
+	probeInterval = flag.Duration("probe-interval", 30*time.Second, "time between two connectivity probes")
+	probeTimeout  = flag.Duration("probe-timeout", 2*time.Second, "timeout of a single connectivity probe")
+
+	connectivity cache // result of the last probe
+)
+
+// probeConnectivity dials the probe address and caches whether it succeeded
+func probeConnectivity() error {
+	conn, err := net.DialTimeout("tcp", *probeAddr, *probeTimeout)
+
+	// an unreachable host is the result of the probe, not a reason to back off
+	if err != nil {
+		connectivity.set("✗")
+		return nil
+	}
+
+	conn.Close()
+	connectivity.set("✓")
+
+	return nil
+}
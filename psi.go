@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const psiSign = "PSI"
+
+var psiResources = flag.String("psi", "memory", "comma separated pressure stall resources shown in the psi segment: cpu, io, memory")
+
+// psiLabels shorten the resource names in the bar
+var psiLabels = map[string]string{"cpu": "cpu", "io": "io", "memory": "mem"}
+
+// readPressure returns the some avg10 value of a /proc/pressure file, i.e. the
+// share of the last ten seconds in which at least one task stalled
+func readPressure(resource string) (float64, error) {
+	content, err := ioutil.ReadFile(*procfsRoot + "/pressure/" + resource)
+
+	if err != nil {
+		return 0, err
+	}
+
+	// some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+
+		if len(fields) < 2 || fields[0] != "some" || !strings.HasPrefix(fields[1], "avg10=") {
+			continue
+		}
+
+		return strconv.ParseFloat(strings.TrimPrefix(fields[1], "avg10="), 64)
+	}
+
+	return 0, fmt.Errorf("%s: no some avg10 field", resource)
+}
+
+// updatePressure shows the stall shares of the -psi resources. Kernels without
+// PSI have no /proc/pressure, the segment is omitted there.
+func updatePressure() string {
+	shown := []string{psiSign}
+
+	for _, resource := range strings.Split(*psiResources, ",") {
+		resource = strings.TrimSpace(resource)
+		avg, err := readPressure(resource)
+
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return psiSign + " " + errText(err)
+		}
+
+		shown = append(shown, psiLabels[resource]+" "+formatFloat(avg))
+	}
+
+	if len(shown) == 1 {
+		return ""
+	}
+
+	return strings.Join(shown, " ")
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	segmentList  = flag.String("segments", "", "comma separated segments to show in this order (all but the optional home, link, gateway, swap, bluetooth, mic, gov, sink, title, play, brightness, week, yday, conn, psi, steal, ent, top, proc, sysctl, kernel, failed, containers, volume and idle if empty)")
+	debug        = flag.Bool("debug", false, "log the value of every segment and the time it took to stderr on each update")
+	padList      = flag.String("pad", "", "minimum widths of segments as name=width list, suffix r to align right, e.g. cpu=7r,mem=7r")
+	collapseList = flag.String("collapse", "", "show only the label of segments whose value is in a boring range, e.g. cpu<20,power>50 (segments: "+
+		"cpu, mem, home, power)")
+	groupList      = flag.String("groups", "", "comma separated groups of segments joined with -group-separator, e.g. net+link,cpu+temps")
+	groupSeparator = flag.String("group-separator", " ", "separator between adjacent segments of the same group")
+	separatorColor = flag.Int("segment-separator-color", 0, "statuscolors index, i.e. colors[] entry of the dwm config, of the separator between segments (uncolored if 0)")
+
+	enabled       = segments // the -segments in display order
+	fieldPads     map[string]fieldPad
+	collapseRules map[string]collapseRule
+	segmentGroups map[string]int
+)
+
+// segmentMetrics names the gauge holding the value of a segment
+var segmentMetrics = map[string]string{
+	"cpu":   "gods_cpu_percent",
+	"mem":   "gods_memory_percent",
+	"home":  "gods_home_used_percent",
+	"power": "gods_battery_percent",
+}
+
+// segment is a named part of the status line
+type segment struct {
+	name   string
+	update func() string
+}
+
+// segments lists all parts of the status line in display order. Segments
+// returning an empty string are omitted.
+var segments = []segment{
+	{"hostname", getHostname},
+	{"net", updateNetUse},
+	{"cpu", updateCPUUse},
+	{"temps", updateTemps},
+	{"throttle", updateThrottle},
+	{"mem", updateMemUse},
+	{"power", updatePower},
+	{"ups", updateUPS},
+	{"hid", updateHIDBattery},
+	{"files", updateFiles},
+	{"weather", updateWeather},
+	{"zones", updateZones},
+	{"date", updateDate},
+}
+
+// optional lists segments which are only shown when named in -segments
+var optional = []segment{
+	{"home", updateHome},
+	{"link", updateLink},
+	{"gateway", updateGateway},
+	{"swap", updateSwapRate},
+	{"bluetooth", updateBluetooth},
+	{"mic", updateMic},
+	{"gov", updateGovernor},
+	{"sink", updateSink},
+	{"title", updateTitle},
+	{"play", updatePlayState},
+	{"brightness", updateBrightness},
+	{"week", updateWeek},
+	{"yday", updateYearDay},
+	{"conn", updateConnections},
+	{"psi", updatePressure},
+	{"steal", updateSteal},
+	{"ent", updateEntropy},
+	{"top", updateTop},
+	{"proc", updateProcs},
+	{"sysctl", updateSysctls},
+	{"kernel", updateKernel},
+	{"failed", updateFailedUnits},
+	{"containers", updateContainers},
+	{"volume", updateVolume},
+	{"idle", updateIdle},
+}
+
+// findSegment looks up a segment by name
+func findSegment(name string) (segment, bool) {
+	for _, s := range append(segments, optional...) {
+		if s.name == name {
+			return s, true
+		}
+	}
+
+	return segment{}, false
+}
+
+// isShown tells whether a segment is part of any status line
+func isShown(name string) bool {
+	lists := [][]segment{enabled}
+
+	for _, b := range bars {
+		lists = append(lists, b.segments)
+	}
+
+	for _, list := range lists {
+		for _, s := range list {
+			if s.name == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// selectSegments resolves the -segments list
+func selectSegments(list string) ([]segment, error) {
+	if list == "" {
+		return segments, nil
+	}
+
+	var selected []segment
+
+	for _, name := range strings.Split(list, ",") {
+		s, ok := findSegment(strings.TrimSpace(name))
+
+		if !ok {
+			return nil, fmt.Errorf("unknown segment %q", name)
+		}
+
+		selected = append(selected, s)
+	}
+
+	return selected, nil
+}
+
+// fieldPad is the minimum width and alignment of a segment
+type fieldPad struct {
+	width int
+	align rune
+}
+
+// parsePads reads the -pad list, skipping malformed widths
+func parsePads(list string) map[string]fieldPad {
+	pads := make(map[string]fieldPad)
+
+	for _, p := range parsePairs(list) {
+		pad := fieldPad{align: 'l'}
+
+		if strings.HasSuffix(p.value, "r") || strings.HasSuffix(p.value, "l") {
+			pad.align = rune(p.value[len(p.value)-1])
+			p.value = p.value[:len(p.value)-1]
+		}
+
+		if width, err := strconv.Atoi(p.value); err == nil {
+			pad.width = width
+			pads[p.key] = pad
+		}
+	}
+
+	return pads
+}
+
+// collapseRule hides the value of a segment below or above a threshold
+type collapseRule struct {
+	below     bool
+	threshold float64
+}
+
+// parseCollapse reads the -collapse list, skipping malformed entries
+func parseCollapse(list string) map[string]collapseRule {
+	rules := make(map[string]collapseRule)
+
+	for _, entry := range strings.Split(list, ",") {
+		op := strings.IndexAny(entry, "<>")
+
+		if op <= 0 {
+			continue
+		}
+
+		if threshold, err := strconv.ParseFloat(entry[op+1:], 64); err == nil {
+			rules[strings.TrimSpace(entry[:op])] = collapseRule{entry[op] == '<', threshold}
+		}
+	}
+
+	return rules
+}
+
+// parseGroups numbers the -groups from 1 by the segments they contain
+func parseGroups(list string) map[string]int {
+	groups := make(map[string]int)
+
+	for i, group := range strings.Split(list, ",") {
+		for _, name := range strings.Split(group, "+") {
+			if name = strings.TrimSpace(name); name != "" {
+				groups[name] = i + 1
+			}
+		}
+	}
+
+	return groups
+}
+
+// collapse reduces text to its label, the part before the first digit, if
+// the value of the segment matches its -collapse rule
+func collapse(name, text string) string {
+	rule, ok := collapseRules[name]
+
+	if !ok {
+		return text
+	}
+
+	value, ok := getMetric(segmentMetrics[name])
+
+	if !ok || (rule.below && value >= rule.threshold) || (!rule.below && value <= rule.threshold) {
+		return text
+	}
+
+	digit := strings.IndexAny(text, "0123456789")
+
+	if digit < 0 {
+		return text
+	}
+
+	return strings.TrimRight(text[:digit], " ")
+}
+
+// statusLine runs all updaters and joins their output
+func statusLine() string {
+	shown := renderSegments(enabled)
+	setCurrent(shown)
+
+	return joinSegments(shown)
+}
+
+// renderSegments runs the updaters and returns the segments which are shown
+// with collapse, squeeze and padding applied
+func renderSegments(list []segment) []shownSegment {
+	var shown []shownSegment
+
+	for _, s := range list {
+		start := time.Now()
+		text := s.update()
+
+		if *debug {
+			log.Printf("%-10s %10v %q", s.name, time.Since(start).Round(time.Microsecond), text)
+		}
+
+		if text == "" {
+			continue
+		}
+
+		text = collapse(s.name, text)
+
+		if squeeze {
+			text = strings.Replace(text, " ", "", -1)
+		}
+
+		if pad, ok := fieldPads[s.name]; ok {
+			text = padField(text, pad.width, pad.align)
+		}
+
+		shown = append(shown, shownSegment{s.name, text})
+	}
+
+	return shown
+}
+
+// joinSegments puts the separators between the shown segments
+func joinSegments(shown []shownSegment) string {
+	var status strings.Builder
+	var lastGroup = 0
+
+	for i, s := range shown {
+		// segments without a group are in group 0 and never join tightly
+		group := segmentGroups[s.Name]
+
+		if i > 0 {
+			if group != 0 && group == lastGroup {
+				status.WriteString(*groupSeparator)
+			} else {
+				status.WriteString(separator())
+			}
+		}
+
+		lastGroup = group
+		status.WriteString(s.Text)
+	}
+
+	return status.String()
+}
+
+// separator returns the field separator in the -segment-separator-color
+func separator() string {
+	if *separatorColor <= 0 {
+		return fieldSeparator
+	}
+
+	return string(rune(*separatorColor)) + fieldSeparator + colorNormal
+}
@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+// BenchmarkStatusLine renders the file based segments from the fixture trees
+// below testdata, so the cost of a tick is measured without any commands
+func BenchmarkStatusLine(b *testing.B) {
+	defer func(sys, proc, labels string, list []segment) {
+		*sysfsRoot, *procfsRoot, *tempLabels, enabled = sys, proc, labels, list
+	}(*sysfsRoot, *procfsRoot, *tempLabels, enabled)
+
+	*sysfsRoot, *procfsRoot = "testdata/sys", "testdata/proc"
+	*tempLabels = "Package id 0"
+
+	var err error
+
+	if enabled, err = selectSegments("net,cpu,gov,temps,throttle,mem,swap,brightness,power,date"); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		statusLine()
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var selftest = flag.Bool("selftest", false, "update every enabled segment once, print OK or ERR with the reason for each and exit with status 1 on failures")
+
+// selfTest runs the background jobs and then every enabled segment once and
+// reports one line per segment: its name, OK or ERR and the shown text or the
+// reason of the failure. It returns the exit status.
+func selfTest(w io.Writer) int {
+	jobErrs := map[string]error{}
+
+	for _, j := range jobs {
+		if err := j.update(); err != nil && jobErrs[j.segment] == nil {
+			jobErrs[j.segment] = err
+		}
+	}
+
+	status := 0
+
+	for _, s := range enabled {
+		failures.Lock()
+		failures.last = nil
+		failures.Unlock()
+
+		text := plain(s.update())
+
+		failures.Lock()
+		err := failures.last
+		failures.Unlock()
+
+		if err == nil {
+			err = jobErrs[s.name]
+		}
+
+		switch {
+		case err != nil:
+			fmt.Fprintf(w, "%s ERR %v\n", s.name, err)
+			status = 1
+		case strings.Contains(text, "ERR"):
+			fmt.Fprintf(w, "%s ERR unknown reason\n", s.name)
+			status = 1
+		case text == "":
+			fmt.Fprintf(w, "%s OK (omitted)\n", s.name)
+		default:
+			fmt.Fprintf(w, "%s OK %s\n", s.name, text)
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+var (
+	tempLabels  = flag.String("temp-labels", "", "comma separated labels of hwmon temperature sensors to show, e.g. Tctl,Tccd1 (see -list-sensors)")
+	listSensors = flag.Bool("list-sensors", false, "print the thermal zones and hwmon sensors with their current values and exit")
+
+	tempUnit     = flag.String("temp-unit", "C", "unit of the temperatures and their thresholds: C or F")
+	tempWarning  = flag.Float64("temp-warning", 0, "temperature in -temp-unit from which a sensor gets the warning color (80°C if 0)")
+	tempCritical = flag.Float64("temp-critical", 0, "temperature in -temp-unit from which a sensor gets the critical color (95°C if 0)")
+
+	tempPrecision = flag.Int("temp-precision", 0, "decimals of the temperatures in the temps segment, 0 to 3")
+)
+
+// sensor is a single hwmon input file
+type sensor struct {
+	chip  string // name of the hwmon device, e.g. "k10temp"
+	label string // content of the _label file or the input name, e.g. "Tctl"
+	input string // path of the _input file
+}
+
+// hwmonSensors enumerates the hwmon inputs of a kind, e.g. "temp" or "fan"
+func hwmonSensors(kind string) []sensor {
+	inputs, _ := filepath.Glob(*sysfsRoot + "/class/hwmon/hwmon*/" + kind + "*_input")
+	sensors := make([]sensor, 0, len(inputs))
+
+	for _, input := range inputs {
+		s := sensor{input: input}
+		s.label = strings.TrimSuffix(filepath.Base(input), "_input")
+
+		if tmp, err := ioutil.ReadFile(strings.TrimSuffix(input, "_input") + "_label"); err == nil {
+			s.label = strings.TrimSpace(string(tmp))
+		}
+
+		if tmp, err := ioutil.ReadFile(filepath.Dir(input) + "/name"); err == nil {
+			s.chip = strings.TrimSpace(string(tmp))
+		}
+
+		sensors = append(sensors, s)
+	}
+
+	return sensors
+}
+
+// readInt reads a file holding a single integer, like most sysfs attributes
+func readInt(path string) (int, error) {
+	tmp, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(strings.TrimSpace(string(tmp)))
+}
+
+// readMilli reads a sysfs value given in thousandths, like millidegrees
+func readMilli(path string) (float64, error) {
+	milli, err := readInt(path)
+
+	return float64(milli) / 1000, err
+}
+
+// toTempUnit converts degrees Celsius, as reported by sysfs, to the -temp-unit
+func toTempUnit(celsius float64) float64 {
+	if *tempUnit == "F" {
+		return celsius*9/5 + 32
+	}
+
+	return celsius
+}
+
+// tempThreshold returns a threshold flag or its default in the -temp-unit
+func tempThreshold(set, celsius float64) float64 {
+	if set != 0 {
+		return set
+	}
+
+	return toTempUnit(celsius)
+}
+
+// colorForTemp selects the dwm color escape for a temperature in -temp-unit
+func colorForTemp(degrees float64) string {
+	if degrees >= tempThreshold(*tempCritical, 95) {
+		return colorCritical
+	} else if degrees >= tempThreshold(*tempWarning, 80) {
+		return colorWarning
+	}
+	return colorNormal
+}
+
+// updateTemps shows the configured hwmon temperature sensors in the order of
+// -temp-labels. It is omitted if none of them exists.
+func updateTemps() string {
+	if *tempLabels == "" {
+		return ""
+	}
+
+	readings, err := readSensors("temp")
+
+	if err != nil {
+		return errText(err)
+	}
+
+	var shown []string
+
+	for _, label := range strings.Split(*tempLabels, ",") {
+		for _, r := range readings {
+			if !r.matches(label) {
+				continue
+			}
+
+			// chip/feature entries are shown by the feature only
+			degrees := toTempUnit(r.value)
+			shown = append(shown, colorForTemp(degrees)+r.label+colorNormal+" "+formatFixed(degrees, *tempPrecision)+"°"+*tempUnit)
+
+			break
+		}
+	}
+
+	return strings.Join(shown, " ")
+}
+
+// printSensors lists the thermal zones and hwmon inputs for configuring the
+// temperature segments
+func printSensors() error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "SOURCE\tNAME\tLABEL\tVALUE")
+
+	zones, _ := filepath.Glob(*sysfsRoot + "/class/thermal/thermal_zone*")
+
+	for _, zone := range zones {
+		zoneType, _ := ioutil.ReadFile(zone + "/type")
+		degrees, err := readMilli(zone + "/temp")
+		value := formatFloat(toTempUnit(degrees)) + "°" + *tempUnit
+
+		if err != nil {
+			value = "?"
+		}
+
+		fmt.Fprintf(w, "thermal\t%s\t%s\t%s\n", filepath.Base(zone), strings.TrimSpace(string(zoneType)), value)
+	}
+
+	source := "hwmon"
+
+	if *sensorsBackend == "lm-sensors" {
+		source = "sensors"
+	}
+
+	for _, kind := range []string{"temp", "fan"} {
+		readings, err := readSensors(kind)
+
+		if err != nil {
+			return err
+		}
+
+		for _, r := range readings {
+			value := formatFloat(toTempUnit(r.value)) + "°" + *tempUnit
+
+			if kind == "fan" {
+				value = formatInt(int(r.value)) + "rpm"
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", source, r.chip, r.label, value)
+		}
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"sync"
+)
+
+var (
+	httpAddr = flag.String("http", "", "serve the current segments as JSON on /status at this address, e.g. :9099 (disabled if empty)")
+
+	// current holds the segments of the last status line
+	current struct {
+		sync.Mutex
+		segments []shownSegment
+	}
+)
+
+// shownSegment is the output of a segment as it was put into the bar
+type shownSegment struct {
+	Name string `json:"name"`
+	Text string `json:"text"`
+}
+
+// setCurrent replaces the segments of the last status line
+func setCurrent(shown []shownSegment) {
+	current.Lock()
+	current.segments = shown
+	current.Unlock()
+}
+
+// getCurrent returns the segments of the last status line
+func getCurrent() []shownSegment {
+	current.Lock()
+	defer current.Unlock()
+
+	return current.segments
+}
+
+// serveStatus writes the segments of the last status line without the dwm
+// color escapes
+func serveStatus(w http.ResponseWriter, r *http.Request) {
+	shown := getCurrent()
+	plainShown := make([]shownSegment, len(shown))
+
+	for i, s := range shown {
+		plainShown[i] = shownSegment{s.Name, plain(s.Text)}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plainShown)
+}
+
+// startHTTP serves the status in the background
+func startHTTP() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", serveStatus)
+
+	go func() {
+		log.Fatal(http.ListenAndServe(*httpAddr, mux))
+	}()
+}
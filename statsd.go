@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+)
+
+var (
+	statsdAddr   = flag.String("statsd", "", "send the metrics as StatsD gauges over UDP to this address each interval, e.g. localhost:8125 (disabled if empty)")
+	statsdPrefix = flag.String("statsd-prefix", "gods.", "prefix of the StatsD gauge names, e.g. gods.cpu_percent")
+
+	statsdConn net.Conn // nil until the first send
+)
+
+// sendStatsd sends all gauges in one datagram of name:value|g lines. UDP
+// doesn't wait for the receiver, failures are only logged and retried with
+// the next update.
+func sendStatsd() {
+	if statsdConn == nil {
+		conn, err := net.Dial("udp", *statsdAddr)
+
+		if err != nil {
+			log.Print("statsd: ", err)
+			return
+		}
+
+		statsdConn = conn
+	}
+
+	var lines strings.Builder
+
+	for _, name := range metricNames() {
+		value, _ := getMetric(name)
+		fmt.Fprintf(&lines, "%s%s:%g|g\n", *statsdPrefix, strings.TrimPrefix(name, "gods_"), value)
+	}
+
+	if lines.Len() == 0 {
+		return
+	}
+
+	statsdConn.SetWriteDeadline(time.Now().Add(100 * time.Millisecond))
+
+	if _, err := statsdConn.Write([]byte(lines.String())); err != nil {
+		log.Print("statsd: ", err)
+	}
+}
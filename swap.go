@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+const swapSign = "SWP"
+
+var (
+	swapInOld, swapOutOld int
+	swapSampled           time.Time // time swapInOld and swapOutOld were read
+)
+
+// updateSwapRate shows the pages swapped in and out per second since the
+// last update
+func updateSwapRate() string {
+	file, err := os.Open(*procfsRoot + "/vmstat")
+
+	if err != nil {
+		return swapSign + " " + errText(err)
+	}
+
+	defer file.Close()
+
+	var swapIn, swapOut, done = 0, 0, 0
+
+	for info := bufio.NewScanner(file); done != 3 && info.Scan(); {
+		var prop, val = "", 0
+
+		if _, err = fmt.Sscanf(info.Text(), "%s %d", &prop, &val); err != nil {
+			continue
+		}
+
+		switch prop {
+		case "pswpin":
+			swapIn = val
+			done |= 1
+		case "pswpout":
+			swapOut = val
+			done |= 2
+		}
+	}
+
+	if done != 3 {
+		return swapSign + " " + errText(errors.New("no swap counters in vmstat"))
+	}
+
+	defer func() { swapInOld, swapOutOld, swapSampled = swapIn, swapOut, time.Now() }()
+
+	if firstSample("swap") {
+		return swapSign + " " + *firstPlaceholder
+	}
+
+	var inRate, outRate = 0, 0
+
+	if elapsed := time.Since(swapSampled).Seconds(); elapsed > 0 {
+		inRate = int(math.Round(float64(swapIn-swapInOld) / elapsed))
+		outRate = int(math.Round(float64(swapOut-swapOutOld) / elapsed))
+	}
+
+	return fmt.Sprintf("%s in:%s out:%s", swapSign, formatInt(inRate), formatInt(outRate))
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const kernelSign = "KERN"
+
+var (
+	sysctlList  = flag.String("sysctls", "sw=vm.swappiness,dr=vm.dirty_ratio", "kernel tunables shown in the sysctl segment as label=name list")
+	sysctlLabel = flag.String("sysctl-label", "VM", "label of the sysctl segment")
+
+	kernelRelease string // read on the first update, it can't change before a reboot
+)
+
+// updateSysctls shows the -sysctls values, e.g. VM sw:60 dr:20. Tunables the
+// kernel doesn't have are skipped, without any the segment is omitted.
+func updateSysctls() string {
+	var shown []string
+
+	for _, p := range parsePairs(*sysctlList) {
+		value, err := ioutil.ReadFile(*procfsRoot + "/sys/" + strings.Replace(p.value, ".", "/", -1))
+
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return *sysctlLabel + " " + errText(err)
+		}
+
+		shown = append(shown, p.key+":"+strings.TrimSpace(string(value)))
+	}
+
+	if len(shown) == 0 {
+		return ""
+	}
+
+	return *sysctlLabel + " " + strings.Join(shown, " ")
+}
+
+// updateKernel shows the release of the running kernel, e.g. KERN 6.1.0
+func updateKernel() string {
+	if kernelRelease == "" {
+		release, err := ioutil.ReadFile(*procfsRoot + "/sys/kernel/osrelease")
+
+		if err != nil {
+			return kernelSign + " " + errText(err)
+		}
+
+		kernelRelease = strings.TrimSpace(string(release))
+	}
+
+	return kernelSign + " " + kernelRelease
+}
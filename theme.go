@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var (
+	themeName = flag.String("theme", "default", "color theme: default, solarized, gruvbox or mono")
+	colorTest = flag.Bool("color-test", false, "output a status line showing every statuscolors index in its color and exit")
+
+	// failures holds the error of the last errText
+	failures struct {
+		sync.Mutex
+		last error
+	}
+)
+
+// palette selects the statuscolors entries, i.e. the colors[] indices of the
+// dwm config, used for each state
+type palette struct {
+	normal, warning, critical string
+}
+
+// themes assume the colors[] of the respective statuscolors config, mono
+// never changes the color
+var themes = map[string]palette{
+	"default":   {"\x01", "\x03", "\x06"},
+	"solarized": {"\x01", "\x04", "\x05"},
+	"gruvbox":   {"\x01", "\x07", "\x08"},
+	"mono":      {"\x01", "\x01", "\x01"},
+}
+
+// applyTheme replaces the color escapes with those of a theme
+func applyTheme(name string) error {
+	p, ok := themes[name]
+
+	if !ok {
+		return fmt.Errorf("unknown theme %q", name)
+	}
+
+	colorNormal, colorWarning, colorCritical = p.normal, p.warning, p.critical
+
+	return nil
+}
+
+// colorTestLine labels every statuscolors escape with its index, i.e. the
+// colors[] entry of the dwm config it selects. 10 is skipped as newline.
+func colorTestLine() string {
+	var samples []string
+
+	for i := 1; i < ' '; i++ {
+		if i != '\n' {
+			samples = append(samples, fmt.Sprintf("%c%d%s", rune(i), i, colorNormal))
+		}
+	}
+
+	return strings.Join(samples, " ")
+}
+
+// errText marks a segment which failed to read its source. The error is kept
+// for the -selftest report.
+func errText(err error) string {
+	failures.Lock()
+	failures.last = err
+	failures.Unlock()
+
+	return colorWarning + "ERR" + colorNormal
+}
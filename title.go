@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var titleWidth = flag.Int("title-width", 40, "maximum length of the focused window title (unlimited if <= 0)")
+
+// updateTitle mirrors the title of the focused window. Outside of X or
+// without a focused window the segment is omitted.
+func updateTitle() string {
+	if os.Getenv("DISPLAY") == "" {
+		return ""
+	}
+
+	active, err := runCommand("xprop", "-root", "_NET_ACTIVE_WINDOW")
+
+	if err != nil {
+		return ""
+	}
+
+	// _NET_ACTIVE_WINDOW(WINDOW): window id # 0x1e00007
+	fields := strings.Fields(string(active))
+
+	if len(fields) == 0 || fields[len(fields)-1] == "0x0" {
+		return ""
+	}
+
+	name, err := runCommand("xprop", "-id", fields[len(fields)-1], "_NET_WM_NAME")
+
+	if err != nil {
+		return ""
+	}
+
+	// _NET_WM_NAME(UTF8_STRING) = "title"
+	quoted := string(name)
+	start, end := strings.Index(quoted, "\""), strings.LastIndex(quoted, "\"")
+
+	if start < 0 || end <= start {
+		return ""
+	}
+
+	title, err := strconv.Unquote(quoted[start : end+1])
+
+	if err != nil {
+		title = quoted[start+1 : end]
+	}
+
+	return truncate(title, *titleWidth)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+const (
+	topSign  = "TOP"
+	procSign = "PROC"
+)
+
+var (
+	topWidth  = flag.Int("top-width", 15, "maximum length of the process name in the top segment (unlimited if <= 0)")
+	procNames = flag.String("proc-names", "", "comma separated process names whose summed cpu usage the proc segment shows, e.g. firefox,chrome")
+)
+
+// procTracker keeps the cpu times of all processes between two samples. Every
+// segment has its own, so each sees the deltas since its last update.
+type procTracker struct {
+	ticks map[int]uint64 // utime+stime of every process at the last sample
+	times cpuTimes       // /proc/stat at the last sample
+}
+
+// procUsage is the cpu time a process used since the previous sample
+type procUsage struct {
+	name  string
+	delta uint64
+}
+
+var topProcs, watchedProcs procTracker
+
+// readProcStat returns the command name and the utime+stime ticks of a process
+func readProcStat(pid int) (string, uint64, error) {
+	content, err := ioutil.ReadFile(*procfsRoot + "/" + strconv.Itoa(pid) + "/stat")
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	// pid (comm) state ppid ..., comm may contain spaces and parentheses
+	stat := string(content)
+	open, closing := strings.IndexByte(stat, '('), strings.LastIndexByte(stat, ')')
+
+	if open < 0 || closing < open {
+		return "", 0, fmt.Errorf("%d: malformed stat", pid)
+	}
+
+	// state is the first field after the command, utime the 12th and stime the 13th
+	fields := strings.Fields(stat[closing+1:])
+
+	if len(fields) < 13 {
+		return "", 0, fmt.Errorf("%d: short stat", pid)
+	}
+
+	utime, err1 := strconv.ParseUint(fields[11], 10, 64)
+	stime, err2 := strconv.ParseUint(fields[12], 10, 64)
+
+	if err1 != nil || err2 != nil {
+		return "", 0, fmt.Errorf("%d: malformed times", pid)
+	}
+
+	return stat[open+1 : closing], utime + stime, nil
+}
+
+// sample returns the usage of the processes seen in the previous sample too,
+// and the ticks one core had in between
+func (t *procTracker) sample() ([]procUsage, float64, error) {
+	entries, err := ioutil.ReadDir(*procfsRoot)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	now, err := readCPUTimes()
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// only processes alive now are kept, so exited ones are pruned every update
+	ticks := make(map[int]uint64, len(t.ticks))
+	var usage []procUsage
+
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+
+		// processes may exit between listing and reading
+		name, used, err := readProcStat(pid)
+
+		if err != nil {
+			continue
+		}
+
+		ticks[pid] = used
+
+		if last, ok := t.ticks[pid]; ok && used >= last {
+			usage = append(usage, procUsage{name, used - last})
+		}
+	}
+
+	elapsed := float64(now.total()-t.times.total()) / float64(cores)
+	t.ticks, t.times = ticks, now
+
+	return usage, elapsed, nil
+}
+
+// updateTop shows the process which used the most cpu time since the last
+// update in percent of one core. It is omitted while all processes are idle.
+func updateTop() string {
+	usage, elapsed, err := topProcs.sample()
+
+	if err != nil {
+		return topSign + " " + errText(err)
+	}
+
+	if firstSample("top") {
+		return topSign + " " + *firstPlaceholder
+	}
+
+	var top procUsage
+
+	for _, u := range usage {
+		if u.delta > top.delta {
+			top = u
+		}
+	}
+
+	if top.name == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%s %s %s%%", topSign, truncate(top.name, *topWidth), formatInt(percent(float64(top.delta), elapsed)))
+}
+
+// updateProcs shows the summed cpu usage of all processes of each of the
+// -proc-names since the last update, e.g. PROC:firefox 45%
+func updateProcs() string {
+	if *procNames == "" {
+		return ""
+	}
+
+	usage, elapsed, err := watchedProcs.sample()
+
+	if err != nil {
+		return procSign + " " + errText(err)
+	}
+
+	first := firstSample("proc")
+	var shown []string
+
+	for _, name := range strings.Split(*procNames, ",") {
+		name = strings.TrimSpace(name)
+		value := *firstPlaceholder
+
+		if !first {
+			var sum uint64
+
+			for _, u := range usage {
+				if u.name == name || (len(name) > 15 && u.name == name[:15]) { // the kernel truncates comm
+					sum += u.delta
+				}
+			}
+
+			value = formatInt(percent(float64(sum), elapsed)) + "%"
+		}
+
+		shown = append(shown, procSign+":"+name+" "+value)
+	}
+
+	return strings.Join(shown, " ")
+}
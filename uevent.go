@@ -2,11 +2,18 @@ package main
 
 import (
 	"bufio"
+	"io/ioutil"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 )
 
+// powerSupply returns the sysfs class holding batteries, AC adapters, UPSs, …
+func powerSupply() string {
+	return *sysfsRoot + "/class/power_supply"
+}
+
 type Hash struct {
 	values map[string]string
 }
@@ -35,20 +42,151 @@ func parseFile(path string) *Hash {
 	return hash
 }
 
+// parsePowerSupplies parses the uevent file of every power_supply device,
+// keyed by the device name (e.g. "BAT0", "AC", "ucsi-source-psy-USBC000:001")
+func parsePowerSupplies() (map[string]*Hash, error) {
+	devices, err := ioutil.ReadDir(powerSupply())
+
+	if err != nil {
+		return nil, err
+	}
+
+	supplies := make(map[string]*Hash, len(devices))
+
+	for _, device := range devices {
+		supplies[device.Name()] = parseFile(powerSupply() + "/" + device.Name() + "/uevent")
+	}
+
+	return supplies, nil
+}
+
+// supplyType returns the type of a power_supply device, e.g. "Battery",
+// "Mains" or "UPS"
+func supplyType(name string) string {
+	if tmp, err := ioutil.ReadFile(powerSupply() + "/" + name + "/type"); err == nil {
+		return strings.TrimSpace(string(tmp))
+	}
+
+	return ""
+}
+
 func (h *Hash) SearchForInt(fields []string) int {
+	value, _ := h.SearchForIntOK(fields)
+
+	return value
+}
+
+// SearchForIntOK converts the first present field and reports whether one was
+// present and parsed
+func (h *Hash) SearchForIntOK(fields []string) (int, bool) {
 	for _, field := range fields {
 		if _, exists := h.values[field]; exists {
-			return h.GetInt(field)
+			return h.GetIntOK(field)
 		}
 	}
 
-	return 0
+	return 0, false
+}
+
+func (h *Hash) SearchForString(fields []string) string {
+	for _, field := range fields {
+		if value, exists := h.values[field]; exists {
+			return value
+		}
+	}
+
+	return ""
 }
 
 func (h *Hash) GetInt(field string) int {
-	if convertedValue, err := strconv.Atoi(h.values[field]); err == nil {
-		return convertedValue
+	value, _ := h.GetIntOK(field)
+
+	return value
+}
+
+// GetIntOK converts a field and reports whether it was present and parsed.
+// Surrounding whitespace and a unit suffix like in "16384000 kB" are ignored.
+func (h *Hash) GetIntOK(field string) (int, bool) {
+	if convertedValue, err := strconv.Atoi(leadingInt(h.values[field])); err == nil {
+		return convertedValue, true
+	}
+
+	return 0, false
+}
+
+// leadingInt cuts the optionally signed integer from the start of a value
+func leadingInt(value string) string {
+	value = strings.TrimSpace(value)
+	end := 0
+
+	if end < len(value) && (value[end] == '-' || value[end] == '+') {
+		end++
+	}
+
+	for end < len(value) && value[end] >= '0' && value[end] <= '9' {
+		end++
+	}
+
+	return value[:end]
+}
+
+// GetIntFold is GetInt with the field name matched case-insensitively
+func (h *Hash) GetIntFold(field string) int {
+	if name, exists := h.fold(field); exists {
+		return h.GetInt(name)
+	}
+
+	return 0
+}
+
+// SearchForIntFold is SearchForInt with the field names matched
+// case-insensitively
+func (h *Hash) SearchForIntFold(fields []string) int {
+	for _, field := range fields {
+		if name, exists := h.fold(field); exists {
+			return h.GetInt(name)
+		}
 	}
 
 	return 0
 }
+
+// fold returns the name under which field is stored, ignoring case. An exact
+// match is preferred and avoids scanning all values.
+func (h *Hash) fold(field string) (string, bool) {
+	if _, exists := h.values[field]; exists {
+		return field, true
+	}
+
+	for name := range h.values {
+		if strings.EqualFold(name, field) {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// Fields returns the names of all parsed values, sorted
+func (h *Hash) Fields() []string {
+	fields := make([]string, 0, len(h.values))
+
+	for field := range h.values {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	return fields
+}
+
+// String returns all parsed values as sorted key=value lines
+func (h *Hash) String() string {
+	var lines []string
+
+	for _, field := range h.Fields() {
+		lines = append(lines, field+"="+h.values[field])
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestLeadingInt(t *testing.T) {
+	tests := []struct{ value, want string }{
+		{" 123 kB", "123"},
+		{"123", "123"},
+		{"-42", "-42"},
+		{"unknown", ""},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if got := leadingInt(test.value); got != test.want {
+			t.Errorf("leadingInt(%q) = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
+
+func TestGetIntOK(t *testing.T) {
+	h := &Hash{values: map[string]string{"MEM": " 123 kB", "PLAIN": "123", "STATUS": "Charging"}}
+
+	tests := []struct {
+		field string
+		want  int
+		ok    bool
+	}{
+		{"MEM", 123, true},
+		{"PLAIN", 123, true},
+		{"STATUS", 0, false},
+		{"MISSING", 0, false},
+	}
+
+	for _, test := range tests {
+		if got, ok := h.GetIntOK(test.field); got != test.want || ok != test.ok {
+			t.Errorf("GetIntOK(%q) = %d, %v, want %d, %v", test.field, got, ok, test.want, test.ok)
+		}
+	}
+}
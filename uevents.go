@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"syscall"
+)
+
+var powerEvents = flag.Bool("power-events", false, "update immediately on kernel power_supply uevents, e.g. plugging in AC, "+
+	"so the power segment changes without waiting for -interval")
+
+// watchPowerEvents listens on the kernel uevent netlink socket and requests an
+// update for every power_supply event. Without the socket, e.g. in some
+// containers, the power segment just keeps polling.
+func watchPowerEvents() {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM, syscall.NETLINK_KOBJECT_UEVENT)
+
+	if err != nil {
+		log.Print("power events: ", err)
+		return
+	}
+
+	// group 1 receives the events as broadcast by the kernel
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: 1}); err != nil {
+		syscall.Close(fd)
+		log.Print("power events: ", err)
+		return
+	}
+
+	go func() {
+		defer syscall.Close(fd)
+		buf := make([]byte, 8192)
+
+		for {
+			// "change@/devices/.../power_supply/AC\x00ACTION=change\x00SUBSYSTEM=power_supply\x00..."
+			n, _, err := syscall.Recvfrom(fd, buf, 0)
+
+			if err != nil {
+				log.Print("power events: ", err)
+				return
+			}
+
+			if bytes.Contains(buf[:n], []byte("\x00SUBSYSTEM=power_supply\x00")) {
+				requestUpdate()
+			}
+		}
+	}()
+}
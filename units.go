@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+const failedSign = "FAIL"
+
+var (
+	failedInterval = flag.Duration("failed-interval", time.Minute, "time between two counts of failed systemd units")
+
+	failedUnits cache // last count of failed units
+)
+
+// fetchFailedUnits counts the failed systemd units. On systems not booted with
+// systemd the cache stays empty and the segment omitted.
+func fetchFailedUnits() error {
+	if _, err := os.Stat("/run/systemd/system"); err != nil {
+		return nil
+	}
+
+	out, err := exec.Command("systemctl", "--failed", "--no-legend", "--plain").Output()
+
+	if err != nil {
+		return err
+	}
+
+	count := bytes.Count(bytes.TrimSpace(out), []byte("\n"))
+
+	if len(bytes.TrimSpace(out)) > 0 {
+		count++
+	}
+
+	color := colorNormal
+
+	if count > 0 {
+		color = colorCritical
+	}
+
+	failedUnits.set(fmt.Sprintf("%s%s%s %s", color, failedSign, colorNormal, formatInt(count)))
+
+	return nil
+}
+
+// updateFailedUnits returns the cached count of failed units
+func updateFailedUnits() string {
+	return failedUnits.get()
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"time"
+)
+
+var (
+	skipUnchanged = flag.Bool("skip-unchanged", false, "don't call xsetroot or print a status line which equals the previous one")
+	clockWake     = flag.Bool("clock-wake", false, "update only when the clock changes, i.e. once a minute with a -date-format and -zone-format without seconds, "+
+		"if that is longer than -interval")
+
+	lastOutput = map[string]string{} // last line of every output, see -skip-unchanged
+)
+
+// clockResolution is the smallest change a time format shows, a second or
+// a minute
+func clockResolution(format string) time.Duration {
+	t := time.Date(2000, 1, 1, 0, 0, 1, 0, time.UTC)
+
+	if t.Format(format) != t.Add(time.Second).Format(format) {
+		return time.Second
+	}
+
+	return time.Minute
+}
+
+// tickInterval is the time between two updates: the -interval or, with
+// -clock-wake and a shown date or zones segment, the resolution of the finest
+// clock if it is coarser. It reports whether the clock sets the interval, as
+// then the ticks must be aligned to show the change on time.
+func tickInterval() (time.Duration, bool) {
+	if !*clockWake {
+		return *interval, false
+	}
+
+	var res time.Duration
+
+	if isShown("date") {
+		res = clockResolution(dateFormat)
+	}
+
+	if isShown("zones") && len(zones) > 0 {
+		if zoneRes := clockResolution(*zoneFormat); res == 0 || zoneRes < res {
+			res = zoneRes
+		}
+	}
+
+	if res > *interval {
+		return res, true
+	}
+
+	return *interval, false
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"time"
+)
+
+var errStuck = errors.New("background update is stuck")
+
+var watchdogLimit = flag.Int("watchdog", 3, "restart a background segment which did not finish an update within this many of its intervals (disabled if <= 0)")
+
+// watchdog restarts jobs whose goroutine hangs, e.g. in a request ignoring
+// its timeout. The segment shows ERR until the new goroutine reports.
+func watchdog() {
+	for range time.Tick(*interval) {
+		for _, j := range jobs {
+			j.Lock()
+			if time.Since(j.reported) > time.Duration(*watchdogLimit)*j.delay {
+				j.generation++
+				j.reported, j.delay = time.Now(), j.interval
+				j.out.set(errText(errStuck))
+
+				go j.poll(j.generation)
+			}
+			j.Unlock()
+		}
+	}
+}
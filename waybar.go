@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// waybarModule is the JSON read by a Waybar custom module with return-type json
+type waybarModule struct {
+	Text       string `json:"text"`
+	Tooltip    string `json:"tooltip"`
+	Class      string `json:"class"`
+	Percentage int    `json:"percentage"`
+}
+
+// waybarLine renders a status line for -output waybar: the text without the
+// color escapes, a tooltip with one segment per line, the worst color tier as
+// class for the CSS and the highest percentage of the shown segments
+func waybarLine(line string) string {
+	module := waybarModule{Text: plain(line), Class: "normal"}
+
+	if colorCritical != colorNormal && strings.Contains(line, colorCritical) {
+		module.Class = "critical"
+	} else if colorWarning != colorNormal && strings.Contains(line, colorWarning) {
+		module.Class = "warning"
+	}
+
+	var tooltip []string
+
+	for _, s := range getCurrent() {
+		tooltip = append(tooltip, s.Name+": "+plain(s.Text))
+
+		if value, ok := getMetric(segmentMetrics[s.Name]); ok && int(math.Round(value)) > module.Percentage {
+			module.Percentage = int(math.Round(value))
+		}
+	}
+
+	module.Tooltip = strings.Join(tooltip, "\n")
+	text, err := json.Marshal(module)
+
+	if err != nil { // can't happen with strings and ints
+		return fmt.Sprintf(`{"text":%q}`, module.Text)
+	}
+
+	return string(text)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	weatherURL      = flag.String("weather-url", "", "URL returning the current weather as a single line of text, e.g. https://wttr.in/?format=%c+%t (disabled if empty)")
+	weatherInterval = flag.Duration("weather-interval", 30*time.Minute, "time between two weather requests")
+	weatherTimeout  = flag.Duration("weather-timeout", 10*time.Second, "timeout of a single weather request")
+
+	weather cache // last successfully fetched weather
+)
+
+// fetchWeather requests the current conditions and stores them in the cache
+func fetchWeather() error {
+	client := http.Client{Timeout: *weatherTimeout}
+	resp, err := client.Get(*weatherURL)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("weather: %s", resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 256))
+
+	if err != nil {
+		return err
+	}
+
+	// wttr.in pads its icons with several spaces, collapse them
+	if text := strings.Join(strings.Fields(string(body)), " "); text != "" {
+		weather.set(text)
+	}
+
+	return nil
+}
+
+// updateWeather returns the cached weather or nothing before the first success
+func updateWeather() string {
+	return weather.get()
+}
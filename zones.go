@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"strings"
+	"time"
+)
+
+var (
+	zoneList   = flag.String("zones", "", "extra clocks as label=zone list, e.g. NY=America/New_York,LON=Europe/London,TYO=Asia/Tokyo")
+	zoneFormat = flag.String("zone-format", "15:04", "time format of the extra clocks")
+)
+
+// zone is a labelled location of the zones segment
+type zone struct {
+	label    string
+	location *time.Location
+}
+
+// zones are the valid -zones, loaded once at startup
+var zones []zone
+
+// loadZones resolves the -zones, logging and skipping unknown ones
+func loadZones() {
+	for _, p := range parsePairs(*zoneList) {
+		location, err := time.LoadLocation(p.value)
+
+		if err != nil {
+			log.Printf("warning: -zones: %v", err)
+			continue
+		}
+
+		zones = append(zones, zone{p.key, location})
+	}
+}
+
+// updateZones shows the time in every zone, e.g. NY 09:14 LON 14:14. Without
+// zones the segment is omitted.
+func updateZones() string {
+	now := time.Now()
+	shown := make([]string, 0, len(zones))
+
+	for _, z := range zones {
+		shown = append(shown, z.label+" "+now.In(z.location).Format(*zoneFormat))
+	}
+
+	return strings.Join(shown, " ")
+}